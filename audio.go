@@ -0,0 +1,53 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+// An interleaved float64 buffer together with the format it is sampled in.
+type AudioData struct {
+	Samples    []float64 // Interleaved samples.
+	SampleRate int       // The sample rate of Samples.
+	Channels   int       // The amount of interleaved channels in Samples.
+}
+
+// Resamples in to toRate, using the rate and channel count carried by in instead
+// of the ones configured on the resampler. Other settings of the resampler still
+// apply. Returns an empty AudioData if the format of in or toRate is invalid.
+func (r *Resampler) ResampleAudio(in AudioData, toRate int) AudioData {
+	if err := validateConfig(in.Channels, in.SampleRate, toRate); err != nil {
+		return AudioData{}
+	}
+
+	target := *r
+	target.FromRate = in.SampleRate
+	target.ToRate = toRate
+	target.Channels = in.Channels
+
+	return AudioData{
+		Samples:    target.ResampleFloat64(in.Samples),
+		SampleRate: toRate,
+		Channels:   in.Channels,
+	}
+}
@@ -0,0 +1,57 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import "testing"
+
+func TestResampleAudio(t *testing.T) {
+	in := AudioData{Samples: sine(500, 2, 100, 44100), SampleRate: 44100, Channels: 2}
+	r := mustResampler(t, 1, 8000, 16000)
+	out := r.ResampleAudio(in, 22050)
+
+	if out.SampleRate != 22050 || out.Channels != 2 {
+		t.Fatalf("got %d Hz with %d channels, want 22050 Hz with 2", out.SampleRate, out.Channels)
+	}
+	assertEqualSamples(t, out.Samples, mustResampler(t, 2, 44100, 22050).ResampleFloat64(in.Samples))
+	if r.FromRate != 8000 || r.ToRate != 16000 || r.Channels != 1 {
+		t.Fatal("ResampleAudio changed the resampler configuration")
+	}
+}
+
+func TestResampleAudioInvalid(t *testing.T) {
+	r := mustResampler(t, 1, 8000, 16000)
+	for _, in := range []AudioData{
+		{Samples: []float64{1}, SampleRate: 0, Channels: 1},
+		{Samples: []float64{1}, SampleRate: 8000, Channels: 0},
+	} {
+		if out := r.ResampleAudio(in, 16000); out.Samples != nil || out.SampleRate != 0 {
+			t.Errorf("expected an empty result for %+v, got %+v", in, out)
+		}
+	}
+	if out := r.ResampleAudio(AudioData{Samples: []float64{1}, SampleRate: 8000, Channels: 1}, 0); out.SampleRate != 0 {
+		t.Errorf("expected an empty result for a zero target rate, got %+v", out)
+	}
+}
@@ -0,0 +1,48 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+)
+
+// Resamples a float64 audio buffer and returns the result with a checksum of
+// it, for verifying a transfer without a second pass. The checksum is the
+// 64-bit FNV-1a hash of the output samples as little-endian IEEE 754 doubles, so
+// identical output always gives the same checksum and the receiver can compute
+// it with any FNV-1a implementation.
+func (r *Resampler) ResampleFloat64Checksummed(data []float64) (out []float64, checksum uint64) {
+	out = r.ResampleFloat64(data)
+	h := fnv.New64a()
+	var buf [8]byte
+	for _, v := range out {
+		binary.LittleEndian.PutUint64(buf[:], math.Float64bits(v))
+		h.Write(buf[:])
+	}
+	return out, h.Sum64()
+}
@@ -0,0 +1,56 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import (
+	"math"
+	"testing"
+)
+
+func TestResampleFloat64Checksummed(t *testing.T) {
+	r := mustResampler(t, 2, 44100, 48000)
+	in := sine(1000, 2, 440, 44100)
+	out, sum := r.ResampleFloat64Checksummed(in)
+	assertEqualSamples(t, out, r.ResampleFloat64(in))
+
+	// FNV-1a written out, as a receiver without hash/fnv would.
+	want := uint64(14695981039346656037)
+	for _, v := range out {
+		bits := math.Float64bits(v)
+		for i := 0; i < 8; i++ {
+			want ^= bits >> (8 * i) & 0xff
+			want *= 1099511628211
+		}
+	}
+	if sum != want {
+		t.Errorf("got checksum %#x, want %#x", sum, want)
+	}
+
+	in[500] += 1e-9
+	if _, changed := r.ResampleFloat64Checksummed(in); changed == sum {
+		t.Error("checksum did not change with the output")
+	}
+}
@@ -0,0 +1,119 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+// Command gomplerate resamples an audio file to a new sample rate.
+//
+// WAV files (16-bit PCM or 32-bit float) are resampled to a WAV file in the same
+// format:
+//
+//	gomplerate -in in.wav -out out.wav -rate 48000
+//
+// Headerless little-endian 16-bit PCM needs its rate and channel count:
+//
+//	gomplerate -in in.raw -out out.raw -rate 48000 -inrate 44100 -channels 2
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ThomasBurgess2000/gomplerate"
+)
+
+func main() {
+	err := run(os.Args[1:], os.Stderr)
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		os.Exit(0)
+	case err != nil:
+		fmt.Fprintln(os.Stderr, "gomplerate:", err)
+		os.Exit(1)
+	}
+}
+
+// Parses the command line in args, resamples the input file to the output file
+// and reports the sample counts and rates to stderr.
+func run(args []string, stderr io.Writer) error {
+	flags := flag.NewFlagSet("gomplerate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	in := flags.String("in", "", "input file")
+	out := flags.String("out", "", "output file")
+	rate := flags.Int("rate", 0, "output sample rate in Hz")
+	inRate := flags.Int("inrate", 0, "input sample rate in Hz, for raw 16-bit PCM input")
+	channels := flags.Int("channels", 0, "channel count, for raw 16-bit PCM input")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	switch {
+	case flags.NArg() > 0:
+		return fmt.Errorf("unexpected arguments %q", flags.Args())
+	case *in == "" || *out == "":
+		return errors.New("both -in and -out are required")
+	case *rate <= 0:
+		return errors.New("-rate must be a positive sample rate")
+	case (*inRate == 0) != (*channels == 0):
+		return errors.New("raw input needs both -inrate and -channels")
+	}
+
+	data, err := os.ReadFile(*in)
+	if err != nil {
+		return err
+	}
+
+	var resampled []byte
+	var fromRate, numChannels, inSamples, outSamples int
+	if *inRate != 0 {
+		r, err := gomplerate.NewResampler(*channels, *inRate, *rate)
+		if err != nil {
+			return err
+		}
+		if resampled, err = r.ResampleBytes(data, binary.LittleEndian); err != nil {
+			return fmt.Errorf("%s: %w", *in, err)
+		}
+		fromRate, numChannels = *inRate, *channels
+		inSamples, outSamples = len(data)/2, len(resampled)/2
+	} else {
+		if _, fromRate, numChannels, inSamples, err = gomplerate.WAVInfo(data); err != nil {
+			return fmt.Errorf("%s: %w", *in, err)
+		}
+		if resampled, err = gomplerate.ResampleWAVBytes(data, *rate); err != nil {
+			return fmt.Errorf("%s: %w", *in, err)
+		}
+		if _, _, _, outSamples, err = gomplerate.WAVInfo(resampled); err != nil {
+			return err
+		}
+	}
+
+	if err := os.WriteFile(*out, resampled, 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(stderr, "%s: %d samples (%d channels) at %d Hz\n", *in, inSamples, numChannels, fromRate)
+	fmt.Fprintf(stderr, "%s: %d samples (%d channels) at %d Hz\n", *out, outSamples, numChannels, *rate)
+	return nil
+}
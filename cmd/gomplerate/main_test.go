@@ -0,0 +1,136 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ThomasBurgess2000/gomplerate"
+)
+
+// Returns a 16-bit PCM WAV file holding a ramp of frames frames.
+func pcmWAV(rate, channels, frames int) []byte {
+	pcm := make([]byte, 2*channels*frames)
+	for i := 0; i < channels*frames; i++ {
+		binary.LittleEndian.PutUint16(pcm[2*i:], uint16(int16(i*97)))
+	}
+	var wav bytes.Buffer
+	wav.WriteString("RIFF")
+	binary.Write(&wav, binary.LittleEndian, uint32(36+len(pcm)))
+	wav.WriteString("WAVEfmt ")
+	binary.Write(&wav, binary.LittleEndian, []uint32{16})
+	binary.Write(&wav, binary.LittleEndian, []uint16{1, uint16(channels)})
+	binary.Write(&wav, binary.LittleEndian, []uint32{uint32(rate), uint32(2 * channels * rate)})
+	binary.Write(&wav, binary.LittleEndian, []uint16{uint16(2 * channels), 16})
+	wav.WriteString("data")
+	binary.Write(&wav, binary.LittleEndian, uint32(len(pcm)))
+	wav.Write(pcm)
+	return wav.Bytes()
+}
+
+func TestRunWAV(t *testing.T) {
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, "in.wav"), filepath.Join(dir, "out.wav")
+	if err := os.WriteFile(in, pcmWAV(44100, 2, 441), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stderr strings.Builder
+	if err := run([]string{"-in", in, "-out", out, "-rate", "48000"}, &stderr); err != nil {
+		t.Fatal(err)
+	}
+	wav, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	format, rate, channels, samples, err := gomplerate.WAVInfo(wav)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != gomplerate.FormatInt16 || rate != 48000 || channels != 2 || samples != 960 {
+		t.Errorf("got format %d at %d Hz with %d channels and %d samples", format, rate, channels, samples)
+	}
+	if want := out + ": 960 samples (2 channels) at 48000 Hz"; !strings.Contains(stderr.String(), want) {
+		t.Errorf("stderr %q does not report %q", stderr.String(), want)
+	}
+}
+
+func TestRunRaw(t *testing.T) {
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, "in.raw"), filepath.Join(dir, "out.raw")
+	raw := make([]byte, 2*2*800)
+	if err := os.WriteFile(in, raw, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stderr strings.Builder
+	if err := run([]string{"-in", in, "-out", out, "-rate", "16000", "-inrate", "8000", "-channels", "2"}, &stderr); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2*len(raw) {
+		t.Errorf("got %d bytes, want %d", len(got), 2*len(raw))
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, "in.wav"), filepath.Join(dir, "out.wav")
+	if err := os.WriteFile(in, pcmWAV(44100, 1, 100), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	odd := filepath.Join(dir, "odd.raw")
+	if err := os.WriteFile(odd, make([]byte, 3), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"-out", out, "-rate", "48000"},
+		{"-in", in, "-rate", "48000"},
+		{"-in", in, "-out", out},
+		{"-in", in, "-out", out, "-rate", "-1"},
+		{"-in", in, "-out", out, "-rate", "48000", "-inrate", "44100"},
+		{"-in", in, "-out", out, "-rate", "48000", "extra"},
+		{"-in", filepath.Join(dir, "missing.wav"), "-out", out, "-rate", "48000"},
+		{"-in", odd, "-out", out, "-rate", "48000", "-inrate", "44100", "-channels", "1"},
+		{"-in", odd, "-out", out, "-rate", "48000"},
+		{"-bogus"},
+	} {
+		if err := run(args, &strings.Builder{}); err == nil {
+			t.Errorf("run(%q): expected an error", args)
+		}
+	}
+	if err := run([]string{"-h"}, &strings.Builder{}); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("got %v for -h, want flag.ErrHelp", err)
+	}
+}
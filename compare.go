@@ -0,0 +1,55 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import "math"
+
+// Reports whether a and b produce the same output for testSignal, within
+// tolerance per sample. Both must have the same channel count and output rate.
+// Outputs that differ in length by at most one frame are compared over the
+// frames they share, since equivalent configurations may round the length of
+// the result differently.
+func Equivalent(a, b *Resampler, testSignal []float64, tolerance float64) bool {
+	if a.Channels != b.Channels || a.ToRate != b.ToRate {
+		return false
+	}
+
+	outA, outB := a.ResampleFloat64(testSignal), b.ResampleFloat64(testSignal)
+	n := len(outA)
+	if len(outB) < n {
+		n = len(outB)
+	}
+	if len(outA)-n > a.Channels || len(outB)-n > a.Channels {
+		return false
+	}
+
+	for i := 0; i < n; i++ {
+		if math.Abs(outA[i]-outB[i]) > tolerance {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import "testing"
+
+func TestEquivalent(t *testing.T) {
+	in := sine(4410, 2, 300, 44100)
+	a := mustResampler(t, 2, 44100, 48000)
+	if !Equivalent(a, a.Clone(), in, 0) {
+		t.Error("a clone should be equivalent")
+	}
+
+	detuned := a.Clone()
+	detuned.FromRate = 44000
+	if Equivalent(a, detuned, in, 1e-6) {
+		t.Error("a different input rate should not be equivalent")
+	}
+	if Equivalent(a, mustResampler(t, 1, 44100, 48000), in, 1) {
+		t.Error("a different channel count should not be equivalent")
+	}
+	if Equivalent(a, mustResampler(t, 2, 44100, 96000), in, 1) {
+		t.Error("a different output rate should not be equivalent")
+	}
+}
+
+func TestCloneIsIndependent(t *testing.T) {
+	a := mustResampler(t, 2, 44100, 48000, WithGain(0.5))
+	b := a.Clone()
+	b.ToRate = 8000
+	if a.ToRate != 48000 {
+		t.Fatal("changing the clone changed the original")
+	}
+	in := sine(1000, 2, 300, 44100)
+	b.ToRate = 48000
+	assertEqualSamples(t, b.ResampleFloat64(in), a.ResampleFloat64(in))
+}
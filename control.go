@@ -0,0 +1,43 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+// Resamples parallel control streams (such as parameter automation) from
+// fromRate to toRate with a single call. Each stream is planar and resampled
+// independently, in the float domain with no audio-specific normalization, and
+// comes out OutputLen long for its own length. Streams are returned as is when
+// the rates are equal. Returns nil if the rates are invalid.
+func ResampleControl(streams [][]float64, fromRate, toRate int) [][]float64 {
+	r, err := NewResampler(1, fromRate, toRate)
+	if err != nil {
+		return nil
+	}
+	out := make([][]float64, len(streams))
+	for i, stream := range streams {
+		out[i] = r.ResampleFloat64(stream)
+	}
+	return out
+}
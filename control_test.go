@@ -0,0 +1,45 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import "testing"
+
+func TestResampleControl(t *testing.T) {
+	// Streams of different lengths, one of them empty.
+	streams := [][]float64{sine(300, 1, 5, 1000), constant(120, 0.25), nil}
+	out := ResampleControl(streams, 1000, 60)
+	if len(out) != len(streams) {
+		t.Fatalf("got %d streams, want %d", len(out), len(streams))
+	}
+	r := mustResampler(t, 1, 1000, 60)
+	for i, stream := range streams {
+		assertEqualSamples(t, out[i], r.ResampleFloat64(stream))
+	}
+
+	if out := ResampleControl(streams, 1000, 0); out != nil {
+		t.Errorf("got %d streams for an invalid rate", len(out))
+	}
+}
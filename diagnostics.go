@@ -0,0 +1,91 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import "math"
+
+// Returns the input samples (as per-channel frame indices) and the weights they
+// are multiplied with to produce the output frame outputIndex of a channel. The
+// interpolation is linear in its input samples, so the weights are found by
+// interpolating unit impulses. They sum to 1. Indices before the first input
+// sample or past the last one stand for the samples at the ends. Returns nil for a negative index.
+func (r *Resampler) Weights(outputIndex int) (inputIndices []int, weights []float64) {
+	if outputIndex < 0 {
+		return nil, nil
+	}
+	if r.FromRate == r.ToRate {
+		return []int{outputIndex}, []float64{1}
+	}
+
+	x := r.ReadPosition(outputIndex)
+	xi0 := float64(uint64(x))
+	if r.integerDelays {
+		return []int{int(xi0)}, []float64{1}
+	}
+
+	interp := r.interpolator()
+	before, size := interp.window()
+	inputIndices = make([]int, size)
+	weights = make([]float64, size)
+	impulse := make([]float64, size)
+	for i := range impulse {
+		impulse[i] = 1
+		inputIndices[i] = int(xi0) - before + i
+		weights[i] = interp.interpolate(impulse, x-xi0)
+		impulse[i] = 0
+	}
+	return inputIndices, weights
+}
+
+// Returns the fractional input frame position that the output frame
+// outputIndex of a channel is interpolated at. Output frame 0 is read at input
+// position 0, and consecutive frames are FromRate/ToRate apart.
+func (r *Resampler) ReadPosition(outputIndex int) float64 {
+	step := float64(r.FromRate) / float64(r.ToRate)
+	return float64(outputIndex) * step
+}
+
+// Returns the largest deviation, in input frames, between the exact position
+// k*FromRate/ToRate of every output frame k and the position the resampler
+// actually reads from, over the output of an interleaved input of inputLen
+// samples. Positions are computed from the frame index rather than
+// accumulated, so the error stays at the rounding error of a single
+// multiplication instead of growing with the length of the input.
+func (r *Resampler) PositionError(inputLen int) float64 {
+	frames := r.OutputLen(inputLen) / r.Channels
+	worst := 0.0
+	for k := 0; k < frames; k++ {
+		// Split the exact position into its integer and fractional part so the
+		// comparison does not lose precision on long inputs. The product is
+		// taken in 64 bits so it cannot overflow a 32-bit int.
+		pos := int64(k) * int64(r.FromRate)
+		whole := pos / int64(r.ToRate)
+		frac := float64(pos%int64(r.ToRate)) / float64(r.ToRate)
+		deviation := math.Abs(r.ReadPosition(k) - float64(whole) - frac)
+		worst = math.Max(worst, deviation)
+	}
+	return worst
+}
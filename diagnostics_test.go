@@ -0,0 +1,115 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWeights(t *testing.T) {
+	in := sine(400, 1, 300, 44100)
+	for _, mode := range []InterpMode{InterpLinear, InterpSpline, InterpSinc} {
+		r := mustResampler(t, 1, 44100, 48000, WithInterpolation(mode))
+		out := r.ResampleFloat64(in)
+		for _, k := range []int{100, 101, 250} {
+			indices, weights := r.Weights(k)
+			if len(indices) != len(weights) {
+				t.Fatalf("mode %d: %d indices for %d weights", mode, len(indices), len(weights))
+			}
+			var sum, v float64
+			for i, w := range weights {
+				sum += w
+				v += w * in[indices[i]]
+			}
+			if math.Abs(sum-1) > 1e-9 {
+				t.Errorf("mode %d, frame %d: weights sum to %v", mode, k, sum)
+			}
+			if math.Abs(v-out[k]) > 1e-12 {
+				t.Errorf("mode %d, frame %d: weighted input is %v, output is %v", mode, k, v, out[k])
+			}
+		}
+	}
+
+	r := mustResampler(t, 1, 44100, 48000)
+	if indices, weights := r.Weights(-1); indices != nil || weights != nil {
+		t.Error("expected nil for a negative index")
+	}
+}
+
+func TestReadPosition(t *testing.T) {
+	r := mustResampler(t, 2, 44100, 48000)
+	for _, k := range []int{0, 1, 160, 1000, 48000} {
+		if got, want := r.ReadPosition(k), float64(k)*44100/48000; got != want {
+			t.Errorf("ReadPosition(%d) = %v, want %v", k, got, want)
+		}
+	}
+
+	// Interpolating a ramp returns the position it was read at.
+	ramp := make([]float64, 2000)
+	for i := range ramp {
+		ramp[i] = float64(i / 2)
+	}
+	out := r.ResampleFloat64(ramp)
+	for k := 0; k < len(out)/2-10; k++ {
+		if got := out[2*k]; math.Abs(got-r.ReadPosition(k)) > 1e-9 {
+			t.Fatalf("output frame %d is %v, but ReadPosition is %v", k, got, r.ReadPosition(k))
+		}
+	}
+}
+
+func TestPositionError(t *testing.T) {
+	for _, rates := range [][2]int{{44100, 48000}, {48000, 44100}, {8000, 192000}, {44100, 44100}} {
+		r := mustResampler(t, 1, rates[0], rates[1])
+		// A minute of input, long enough for an accumulated step to drift.
+		if got := r.PositionError(60 * rates[0]); got > 1e-8 {
+			t.Errorf("%d Hz to %d Hz: worst position error is %v input frames", rates[0], rates[1], got)
+		}
+	}
+	if got := mustResampler(t, 2, 44100, 48000).PositionError(0); got != 0 {
+		t.Errorf("got %v for an empty input, want 0", got)
+	}
+}
+
+func TestReadPositionDoesNotDrift(t *testing.T) {
+	r := mustResampler(t, 1, 44100, 48000)
+	// The last output frame of ten minutes, read exactly where it belongs.
+	k := 600*48000 - 1
+	if got, want := r.ReadPosition(k), float64(k)*44100/48000; math.Abs(got-want) > 1e-6 {
+		t.Errorf("ReadPosition(%d) = %v, want %v", k, got, want)
+	}
+
+	// A ramp resampled in one go has no drift at its end either.
+	ramp := make([]float64, 44100*30)
+	for i := range ramp {
+		ramp[i] = float64(i)
+	}
+	out := r.ResampleFloat64(ramp)
+	last := len(out) - 100
+	if got, want := out[last], float64(last)*44100/48000; math.Abs(got-want) > 1e-6 {
+		t.Errorf("output frame %d is %v, want %v", last, got, want)
+	}
+}
@@ -0,0 +1,95 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+// Resamples a float64 audio buffer and applies a gain envelope to it in the
+// same pass: the gain is folded into the interpolation of every output sample,
+// like WithGain. The envelope is sampled at envelopeRate (usually a low control
+// rate) and is linearly interpolated to the time of every output frame; the
+// same gain is applied to all channels of a frame. Before the first and after
+// the last envelope point, the nearest point is held. Returns the resampled
+// buffer, or nil if envelope is empty or envelopeRate is below 1.
+func (r *Resampler) ResampleWithEnvelope(data []float64, envelope []float64, envelopeRate int) []float64 {
+	if len(envelope) == 0 || envelopeRate < 1 {
+		return nil
+	}
+	env := &gainEnvelope{points: envelope, scale: float64(envelopeRate) / float64(r.FromRate)}
+
+	if r.FromRate == r.ToRate {
+		if len(data) == 0 || r.outputTooLarge(len(data)) {
+			return nil
+		}
+		// The input is returned as is, so the copy is the only pass
+		out := make([]float64, len(data))
+		for i, v := range data {
+			out[i] = v * r.outputGain() * env.at(float64(i/r.Channels))
+		}
+		return out
+	}
+	target := *r
+	target.envelope = env
+	return target.ResampleFloat64(data)
+}
+
+// A gain envelope over the input frames of a resampler.
+type gainEnvelope struct {
+	points []float64
+	scale  float64 // Envelope points per input frame.
+}
+
+// Returns the gain at the fractional input frame position x.
+func (e *gainEnvelope) at(x float64) float64 {
+	return envelopeAt(e.points, x*e.scale)
+}
+
+// Linearly interpolates envelope at the fractional index pos.
+func envelopeAt(envelope []float64, pos float64) float64 {
+	if pos <= 0 {
+		return envelope[0]
+	}
+	i := int(pos)
+	if i >= len(envelope)-1 {
+		return envelope[len(envelope)-1]
+	}
+	t := pos - float64(i)
+	return envelope[i]*(1-t) + envelope[i+1]*t
+}
+
+// Resamples a main signal together with a sidechain (control) signal at the same
+// rate, reading both at exactly the same input positions so their output frames
+// stay aligned one to one. side is either interleaved like main, or mono with
+// one sample per frame of main. side is only interpolated, in the same mode as
+// main, without the filters, gain or other processing applied to main. Returns
+// both resampled buffers.
+func (r *Resampler) ResampleSidechain(main, side []float64) (mainOut, sideOut []float64) {
+	channels := r.Channels
+	if r.Channels > 1 && len(side) == len(main)/r.Channels {
+		channels = 1
+	}
+	sideResampler := r.interpolateOnly(channels, r.FromRate, r.ToRate)
+	sideResampler.integerDelays = r.integerDelays
+	return r.ResampleFloat64(main), sideResampler.ResampleFloat64(side)
+}
@@ -0,0 +1,79 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import (
+	"math"
+	"testing"
+)
+
+func TestResampleWithEnvelope(t *testing.T) {
+	in := constant(2*44100*2, 1)
+	for _, to := range []int{48000, 44100} {
+		r := mustResampler(t, 2, 44100, to, WithGain(0.5))
+		out := r.ResampleWithEnvelope(in, []float64{1, 0}, 1)
+		if len(out) != r.OutputLen(len(in)) {
+			t.Fatalf("%d Hz: got %d samples, want %d", to, len(out), r.OutputLen(len(in)))
+		}
+		// The envelope fades from 1 to 0 over the first second, then holds 0.
+		for _, check := range []struct{ frame, want float64 }{{0, 0.5}, {0.25, 0.375}, {0.5, 0.25}, {1.5, 0}} {
+			i := int(check.frame * float64(to))
+			for c := 0; c < 2; c++ {
+				if got := out[2*i+c]; math.Abs(got-check.want) > 1e-9 {
+					t.Fatalf("%d Hz: channel %d at %vs is %v, want %v", to, c, check.frame, got, check.want)
+				}
+			}
+		}
+	}
+
+	r := mustResampler(t, 2, 44100, 48000)
+	tone := sine(1000, 2, 440, 44100)
+	assertEqualSamples(t, r.ResampleWithEnvelope(tone, []float64{1}, 100), r.ResampleFloat64(tone))
+	if r.ResampleWithEnvelope(tone, nil, 100) != nil || r.ResampleWithEnvelope(tone, []float64{1}, 0) != nil {
+		t.Error("expected nil for an empty envelope or an invalid envelope rate")
+	}
+}
+
+func TestResampleSidechain(t *testing.T) {
+	main := sine(2000, 2, 440, 44100)
+	r := mustResampler(t, 2, 44100, 48000, WithOutputDCBlock(true), WithGain(0.5))
+
+	// side is only interpolated, so a constant control signal stays constant.
+	mainOut, sideOut := r.ResampleSidechain(main, constant(2000, 1))
+	assertEqualSamples(t, mainOut, r.ResampleFloat64(main))
+	if len(sideOut) != len(mainOut)/2 {
+		t.Fatalf("got %d side frames for %d main frames", len(sideOut), len(mainOut)/2)
+	}
+	for i, v := range sideOut {
+		if v != 1 {
+			t.Fatalf("side sample %d is %v, want 1", i, v)
+		}
+	}
+
+	side := sine(2000, 2, 50, 44100)
+	_, sideOut = r.ResampleSidechain(main, side)
+	assertEqualSamples(t, sideOut, mustResampler(t, 2, 44100, 48000).ResampleFloat64(side))
+}
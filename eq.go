@@ -0,0 +1,119 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import (
+	"fmt"
+	"math"
+)
+
+// The shape of an EQ band.
+type BandType int
+
+const (
+	BandPeaking   BandType = iota // Boosts or cuts around the frequency.
+	BandLowShelf                  // Boosts or cuts below the frequency.
+	BandHighShelf                 // Boosts or cuts above the frequency.
+)
+
+// A band of the EQ set with WithEQ.
+type Band struct {
+	Type   BandType
+	Freq   float64 // Center or corner frequency in Hz.
+	GainDB float64 // Boost (positive) or cut (negative) in dB.
+	Q      float64 // Width of the band; 0.707 gives a smooth shelf.
+}
+
+// Applies an EQ made of the given bands to every input channel before
+// interpolation, each band as a biquad from the RBJ audio EQ cookbook. Band
+// frequencies must lie between 0 and the Nyquist frequency of FromRate. Like
+// the other input filters, it is not applied when FromRate equals ToRate.
+func WithEQ(bands []Band) Option {
+	return func(r *Resampler) error {
+		eq, err := newEQ(float64(r.FromRate), bands)
+		if err != nil {
+			return err
+		}
+		r.eq = eq
+		r.eqBands = append([]Band(nil), bands...)
+		return nil
+	}
+}
+
+// Checks the EQ bands against sampleRate and creates their biquads.
+func newEQ(sampleRate float64, bands []Band) ([]biquad, error) {
+	nyquist := sampleRate / 2
+	eq := make([]biquad, len(bands))
+	for i, band := range bands {
+		if !(band.Freq > 0 && band.Freq < nyquist) {
+			return nil, fmt.Errorf("EQ band %d frequency must be between 0 and %v Hz (got %v)", i, nyquist, band.Freq)
+		}
+		if !(band.Q > 0) {
+			return nil, fmt.Errorf("EQ band %d Q must be positive (got %v)", i, band.Q)
+		}
+		if math.IsNaN(band.GainDB) || math.IsInf(band.GainDB, 0) {
+			return nil, fmt.Errorf("EQ band %d gain must be a finite number (got %v)", i, band.GainDB)
+		}
+		f, err := newBand(sampleRate, band)
+		if err != nil {
+			return nil, fmt.Errorf("EQ band %d: %w", i, err)
+		}
+		eq[i] = f
+	}
+	return eq, nil
+}
+
+// Creates the biquad of an EQ band.
+func newBand(sampleRate float64, band Band) (biquad, error) {
+	a := math.Pow(10, band.GainDB/40)
+	w := 2 * math.Pi * band.Freq / sampleRate
+	cos, alpha := math.Cos(w), math.Sin(w)/(2*band.Q)
+	shelf := 2 * math.Sqrt(a) * alpha
+
+	var b0, b1, b2, a0, a1, a2 float64
+	switch band.Type {
+	case BandPeaking:
+		b0, b1, b2 = 1+alpha*a, -2*cos, 1-alpha*a
+		a0, a1, a2 = 1+alpha/a, -2*cos, 1-alpha/a
+	case BandLowShelf:
+		b0 = a * ((a + 1) - (a-1)*cos + shelf)
+		b1 = 2 * a * ((a - 1) - (a+1)*cos)
+		b2 = a * ((a + 1) - (a-1)*cos - shelf)
+		a0 = (a + 1) + (a-1)*cos + shelf
+		a1 = -2 * ((a - 1) + (a+1)*cos)
+		a2 = (a + 1) + (a-1)*cos - shelf
+	case BandHighShelf:
+		b0 = a * ((a + 1) + (a-1)*cos + shelf)
+		b1 = -2 * a * ((a - 1) + (a+1)*cos)
+		b2 = a * ((a + 1) + (a-1)*cos - shelf)
+		a0 = (a + 1) - (a-1)*cos + shelf
+		a1 = 2 * ((a - 1) - (a+1)*cos)
+		a2 = (a + 1) - (a-1)*cos - shelf
+	default:
+		return biquad{}, fmt.Errorf("unknown band type %d", band.Type)
+	}
+	return biquad{b0: b0 / a0, b1: b1 / a0, b2: b2 / a0, a1: a1 / a0, a2: a2 / a0}, nil
+}
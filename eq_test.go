@@ -0,0 +1,65 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWithEQ(t *testing.T) {
+	tests := []struct {
+		band          BandType
+		boosted, flat float64 // Frequencies the 6 dB boost should and should not reach.
+	}{
+		{BandPeaking, 1000, 10000},
+		{BandLowShelf, 100, 10000},
+		{BandHighShelf, 10000, 100},
+	}
+	plain := mustResampler(t, 1, 44100, 48000)
+	for _, tt := range tests {
+		r := mustResampler(t, 1, 44100, 48000, WithEQ([]Band{{Type: tt.band, Freq: 1000, GainDB: 6, Q: 0.707}}))
+		// Relative to the interpolation alone, which rolls off high frequencies.
+		gain := func(freq float64) float64 {
+			in := sine(44100, 1, freq, 44100)
+			amp, _ := tone(r.ResampleFloat64(in)[10000:], 1, 0, freq, 48000)
+			ref, _ := tone(plain.ResampleFloat64(in)[10000:], 1, 0, freq, 48000)
+			return 20 * math.Log10(amp/ref)
+		}
+		if db := gain(tt.boosted); math.Abs(db-6) > 0.3 {
+			t.Errorf("band type %d: %v Hz is boosted by %.2f dB, want 6", tt.band, tt.boosted, db)
+		}
+		if db := gain(tt.flat); math.Abs(db) > 0.3 {
+			t.Errorf("band type %d: %v Hz is boosted by %.2f dB, want 0", tt.band, tt.flat, db)
+		}
+	}
+
+	for _, band := range []Band{{Freq: 30000, Q: 1}, {Freq: 0, Q: 1}, {Freq: 1000, Q: 0}} {
+		if _, err := NewResampler(1, 44100, 48000, WithEQ([]Band{band})); err == nil {
+			t.Errorf("expected an error for %+v", band)
+		}
+	}
+}
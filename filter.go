@@ -0,0 +1,238 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import "math"
+
+// A second order IIR filter section (transposed direct form II).
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	z1, z2             float64
+}
+
+// Creates a high-pass biquad from the RBJ audio EQ cookbook.
+func newHighPass(sampleRate, freq, q float64) biquad {
+	w := 2 * math.Pi * freq / sampleRate
+	cos, alpha := math.Cos(w), math.Sin(w)/(2*q)
+	a0 := 1 + alpha
+	return biquad{
+		b0: (1 + cos) / 2 / a0,
+		b1: -(1 + cos) / a0,
+		b2: (1 + cos) / 2 / a0,
+		a1: -2 * cos / a0,
+		a2: (1 - alpha) / a0,
+	}
+}
+
+// Creates a low-pass biquad from the RBJ audio EQ cookbook.
+func newLowPass(sampleRate, freq, q float64) biquad {
+	w := 2 * math.Pi * freq / sampleRate
+	cos, alpha := math.Cos(w), math.Sin(w)/(2*q)
+	a0 := 1 + alpha
+	return biquad{
+		b0: (1 - cos) / 2 / a0,
+		b1: (1 - cos) / a0,
+		b2: (1 - cos) / 2 / a0,
+		a1: -2 * cos / a0,
+		a2: (1 - alpha) / a0,
+	}
+}
+
+func (f *biquad) process(x float64) float64 {
+	y := f.b0*x + f.z1
+	f.z1 = f.b1*x - f.a1*y + f.z2
+	f.z2 = f.b2*x - f.a2*y
+	return y
+}
+
+// Processes the samples of one channel in order, keeping its own state.
+type sampleFilter interface {
+	process(x float64) float64
+}
+
+// Creates fresh instances of the filters applied to every input channel before
+// interpolation, in order. Returns nil if there are none.
+func (r *Resampler) inputFilters() []sampleFilter {
+	var filters []sampleFilter
+	if r.gate != nil {
+		g := *r.gate
+		filters = append(filters, &g)
+	}
+	for _, band := range r.eq {
+		f := band
+		filters = append(filters, &f)
+	}
+	if r.antiAliased() {
+		filters = append(filters, antiAliasFilter(float64(r.FromRate), float64(r.ToRate))...)
+	}
+	return filters
+}
+
+// The decimation factor from which the input is low-passed even without
+// AntiAlias.
+const heavyDecimation = 100
+
+// Reports whether the input is low-passed before downsampling, because of
+// AntiAlias or a decimation by heavyDecimation or more.
+func (r *Resampler) antiAliased() bool {
+	return r.ToRate < r.FromRate && (r.AntiAlias || r.FromRate >= heavyDecimation*r.ToRate)
+}
+
+// Sections of the Butterworth low-pass used by AntiAlias.
+const antiAliasSections = 4
+
+// Creates an 8th order Butterworth low-pass at 90% of the Nyquist frequency of
+// toRate, as a cascade of biquads.
+func antiAliasFilter(fromRate, toRate float64) []sampleFilter {
+	cutoff := 0.9 * toRate / 2
+	filters := make([]sampleFilter, antiAliasSections)
+	for k := range filters {
+		q := 1 / (2 * math.Cos(float64(2*k+1)*math.Pi/(4*antiAliasSections)))
+		filters[k] = &settledLowPass{biquad: newLowPass(fromRate, cutoff, q)}
+	}
+	return filters
+}
+
+// A low-pass biquad that starts settled at its first input, as if it had been
+// held since forever, instead of rising from silence. Decimating by thousands
+// would otherwise start with seconds of the filter settling.
+type settledLowPass struct {
+	biquad
+	started bool
+}
+
+func (f *settledLowPass) process(x float64) float64 {
+	if !f.started {
+		// A low-pass passes DC at unity gain
+		f.z1 = (1 - f.b0) * x
+		f.z2 = (f.b2 - f.a2) * x
+		f.started = true
+	}
+	return f.biquad.process(x)
+}
+
+// Creates fresh instances of the filters applied to every resampled output
+// channel, in order. Returns nil if there are none.
+func (r *Resampler) outputFilters() []sampleFilter {
+	var filters []sampleFilter
+	if r.dcBlock {
+		filters = append(filters, newDCBlocker(float64(r.ToRate)))
+	}
+	return filters
+}
+
+// Filters every stride-th sample of a resampled channel in place.
+func (r *Resampler) filterOutputChannel(data []float64, stride int) {
+	filters := r.outputFilters()
+	if filters == nil {
+		return
+	}
+	for i := 0; i < len(data); i += stride {
+		for _, f := range filters {
+			data[i] = f.process(data[i])
+		}
+	}
+}
+
+// Returns a filtered copy of a channel, or the channel itself if there are no
+// input filters. With WithZeroPhaseFilters the filters run over the channel
+// forwards and then backwards, with fresh state, cancelling their phase shift.
+func (r *Resampler) filterChannel(data []float64) []float64 {
+	filters := r.inputFilters()
+	if filters == nil {
+		return data
+	}
+	filtered := make([]float64, len(data))
+	for i, v := range data {
+		for _, f := range filters {
+			v = f.process(v)
+		}
+		filtered[i] = v
+	}
+	if r.zeroPhase {
+		filters = r.inputFilters()
+		for i := len(filtered) - 1; i >= 0; i-- {
+			for _, f := range filters {
+				filtered[i] = f.process(filtered[i])
+			}
+		}
+	}
+	return filtered
+}
+
+// A noise gate that silences a channel while its level stays below a threshold.
+type noiseGate struct {
+	threshold float64 // Linear level below which the gate closes.
+	attack    float64 // Per-sample smoothing of the gain while opening.
+	release   float64 // Per-sample smoothing of the level and the gain while closing.
+	level     float64
+	gain      float64
+	settings  [3]float64 // Threshold in dB, attack and release in ms, to rebuild it for another rate.
+}
+
+func newNoiseGate(sampleRate, thresholdDB, attackMs, releaseMs float64) *noiseGate {
+	return &noiseGate{
+		settings:  [3]float64{thresholdDB, attackMs, releaseMs},
+		threshold: math.Pow(10, thresholdDB/20),
+		attack:    smoothing(sampleRate, attackMs),
+		release:   smoothing(sampleRate, releaseMs),
+	}
+}
+
+func (g *noiseGate) process(x float64) float64 {
+	g.level = math.Max(math.Abs(x), g.level*g.release)
+	if g.level >= g.threshold {
+		g.gain = 1 - (1-g.gain)*g.attack
+	} else {
+		g.gain *= g.release
+	}
+	return x * g.gain
+}
+
+// Returns the coefficient of a one-pole smoother with a time constant of ms.
+func smoothing(sampleRate, ms float64) float64 {
+	if ms <= 0 {
+		return 0
+	}
+	return math.Exp(-1000 / (ms * sampleRate))
+}
+
+// A one-pole DC blocker: a differentiator followed by a leaky integrator.
+type dcBlocker struct {
+	pole   float64
+	x1, y1 float64
+}
+
+// Creates a DC blocker with a cutoff of about 10 Hz.
+func newDCBlocker(sampleRate float64) *dcBlocker {
+	return &dcBlocker{pole: math.Exp(-2 * math.Pi * 10 / sampleRate)}
+}
+
+func (f *dcBlocker) process(x float64) float64 {
+	y := x - f.x1 + f.pole*f.y1
+	f.x1, f.y1 = x, y
+	return y
+}
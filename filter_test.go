@@ -0,0 +1,174 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import (
+	"math"
+	"testing"
+)
+
+func energy(data []float64) float64 {
+	var e float64
+	for _, v := range data {
+		e += v * v
+	}
+	return e
+}
+
+// Returns the energy of data relative to ref, in dB.
+func relativeDB(data, ref []float64) float64 {
+	return 10 * math.Log10(energy(data)/energy(ref))
+}
+
+// Without AntiAlias, downsampling only interpolates: a tone above the new
+// Nyquist frequency folds back at full level instead of being filtered.
+func TestNoFilteringByDefault(t *testing.T) {
+	r := mustResampler(t, 1, 48000, 8000)
+	if r.AntiAlias {
+		t.Fatal("AntiAlias should be off by default")
+	}
+	alias := r.ResampleFloat64(sine(48000, 1, 6500, 48000))
+	pass := r.ResampleFloat64(sine(48000, 1, 1000, 48000))
+	if db := relativeDB(alias[100:], pass[100:]); math.Abs(db) > 0.5 {
+		t.Fatalf("the tone above Nyquist is %.1f dB relative to the one below, want about 0", db)
+	}
+}
+
+// Every channel is filtered with its own state, so a channel comes out the
+// same as when it is resampled on its own.
+func TestAntiAliasStatePerChannel(t *testing.T) {
+	in := sine(5000, 3, 6000, 48000)
+	for i := 1; i < len(in); i += 3 {
+		in[i] = 0
+	}
+	multi := mustResampler(t, 3, 48000, 8000)
+	multi.AntiAlias = true
+	out, err := Deinterleave(multi.ResampleFloat64(in), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	channels, err := Deinterleave(in, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mono := mustResampler(t, 1, 48000, 8000)
+	mono.AntiAlias = true
+	for c := range channels {
+		assertEqualSamples(t, out[c], mono.ResampleFloat64(channels[c]))
+	}
+	for i, v := range out[1] {
+		if v != 0 {
+			t.Fatalf("silent channel picked up %v at sample %d", v, i)
+		}
+	}
+}
+
+func rms(data []float64) float64 {
+	return math.Sqrt(energy(data) / float64(len(data)))
+}
+
+func TestWithNoiseGate(t *testing.T) {
+	in := make([]float64, 44100)
+	for i := range in {
+		in[i] = 0.001 * math.Sin(float64(i)*1.7)
+		if i > 10000 && i < 20000 {
+			in[i] += 0.5 * math.Sin(2*math.Pi*440*float64(i)/44100)
+		}
+	}
+	r := mustResampler(t, 1, 44100, 48000, WithNoiseGate(-40, 1, 20))
+	out := r.ResampleFloat64(in)
+
+	if got := rms(out[1000:9000]); got > 1e-6 {
+		t.Errorf("noise before the burst has an RMS of %v, want it gated", got)
+	}
+	if got := rms(out[30000:40000]); got > 1e-5 {
+		t.Errorf("noise after the burst has an RMS of %v, want it gated", got)
+	}
+	if got := rms(out[13000:20000]); math.Abs(got-0.5/math.Sqrt2) > 0.01 {
+		t.Errorf("the burst has an RMS of %v, want %v", got, 0.5/math.Sqrt2)
+	}
+
+	var blocks []float64
+	r.ResampleFloat64Blocks(in, 100, func(block []float64) { blocks = append(blocks, block...) })
+	assertEqualSamples(t, blocks, out)
+
+	for _, args := range [][3]float64{{math.NaN(), 1, 1}, {-40, -1, 1}, {-40, 1, math.NaN()}} {
+		if _, err := NewResampler(1, 44100, 48000, WithNoiseGate(args[0], args[1], args[2])); err == nil {
+			t.Errorf("expected an error for %v", args)
+		}
+	}
+}
+
+func TestWithOutputDCBlock(t *testing.T) {
+	in := sine(48000, 2, 440, 44100)
+	for i := range in {
+		in[i] = 0.3*in[i] + 0.25
+	}
+	mean := func(data []float64) float64 {
+		var sum float64
+		for _, v := range data {
+			sum += v
+		}
+		return sum / float64(len(data))
+	}
+
+	out := mustResampler(t, 2, 44100, 48000).ResampleFloat64(in)
+	if m := mean(out[len(out)/2:]); math.Abs(m-0.25) > 1e-3 {
+		t.Fatalf("the offset is %v without the DC blocker, want 0.25", m)
+	}
+	r := mustResampler(t, 2, 44100, 48000, WithOutputDCBlock(true))
+	blocked := r.ResampleFloat64(in)
+	if m := mean(blocked[len(blocked)/2:]); math.Abs(m) > 1e-3 {
+		t.Fatalf("the offset is %v with the DC blocker, want 0", m)
+	}
+
+	low := mustResampler(t, 2, 44100, 48000, WithOutputDCBlock(true), WithLowMemory(true))
+	assertEqualSamples(t, low.ResampleFloat64(in), blocked)
+	var blocks []float64
+	r.ResampleFloat64Blocks(in, 1000, func(block []float64) { blocks = append(blocks, block...) })
+	assertEqualSamples(t, blocks, blocked)
+}
+
+func TestAntiAlias(t *testing.T) {
+	r := mustResampler(t, 1, 48000, 8000)
+	r.AntiAlias = true
+	pass := r.ResampleFloat64(sine(48000, 1, 1000, 48000))
+	alias := r.ResampleFloat64(sine(48000, 1, 6500, 48000))
+	if db := relativeDB(alias[100:], pass[100:]); db > -40 {
+		t.Errorf("6.5 kHz folds back at %.1f dB, want below -40 dB", db)
+	}
+	if amp, _ := tone(pass[100:], 1, 0, 1000, 8000); math.Abs(amp-0.8) > 0.01 {
+		t.Errorf("1 kHz has an amplitude of %v after filtering, want 0.8", amp)
+	}
+
+	// Upsampling is left alone.
+	in := sine(1000, 1, 440, 8000)
+	up := mustResampler(t, 1, 8000, 48000)
+	want := up.ResampleFloat64(in)
+	up.AntiAlias = true
+	assertEqualSamples(t, up.ResampleFloat64(in), want)
+}
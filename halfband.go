@@ -0,0 +1,105 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import "math"
+
+// Taps on each side of the midpoint of a half-band interpolator.
+const halfBandTaps = 16
+
+// The weights of the input samples around a midpoint, from the nearest outwards.
+// The even taps of a half-band filter are zero apart from the center, so only
+// these are needed to compute the new samples between the existing ones.
+var halfBandWeights = func() []float64 {
+	weights := make([]float64, halfBandTaps)
+	sum := 0.0
+	for k := range weights {
+		t := float64(k) + 0.5
+		window := 0.42 + 0.5*math.Cos(math.Pi*t/halfBandTaps) + 0.08*math.Cos(2*math.Pi*t/halfBandTaps)
+		weights[k] = window * math.Sin(math.Pi*t) / (math.Pi * t)
+		sum += 2 * weights[k]
+	}
+	// Normalize to unity DC gain
+	for k := range weights {
+		weights[k] /= sum
+	}
+	return weights
+}()
+
+// Upsamples a float64 audio buffer by exactly 2^stages with a cascade of
+// half-band interpolators, each doubling the rate: the existing samples are kept
+// and a windowed-sinc midpoint is computed between every two of them. Only the
+// channel count of the resampler is used, not its rates. A trailing partial
+// frame is dropped. Returns data itself if stages is 0 or less, and nil if the
+// output would exceed the limit set with WithMaxOutputSamples.
+func (r *Resampler) UpsampleHalfBandCascade(data []float64, stages int) []float64 {
+	if stages <= 0 {
+		return data[:]
+	}
+	frames := len(data) / r.Channels
+	if r.maxOutput > 0 {
+		n := frames * r.Channels
+		for i := 0; i < stages && n <= r.maxOutput; i++ {
+			n *= 2
+		}
+		if n > r.maxOutput {
+			return nil
+		}
+	}
+	channels := r.splitChannels(data[:frames*r.Channels])
+	for c := range channels {
+		for i := 0; i < stages; i++ {
+			channels[c] = upsampleHalfBand(channels[c])
+		}
+	}
+
+	return interleave(channels)
+}
+
+// Upsamples a channel by 2 with a half-band interpolator. Samples past the ends
+// of the channel are taken to be equal to the samples at the ends.
+func upsampleHalfBand(data []float64) []float64 {
+	n := len(data)
+	out := make([]float64, 2*n)
+	at := func(i int) float64 {
+		if i < 0 {
+			return data[0]
+		}
+		if i >= n {
+			return data[n-1]
+		}
+		return data[i]
+	}
+	for i, v := range data {
+		mid := 0.0
+		for k, w := range halfBandWeights {
+			mid += w * (at(i-k) + at(i+1+k))
+		}
+		out[2*i] = v
+		out[2*i+1] = mid
+	}
+	return out
+}
@@ -0,0 +1,83 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import (
+	"math"
+	"testing"
+)
+
+func TestUpsampleHalfBandCascade(t *testing.T) {
+	in := sine(4000, 2, 5000, 44100)
+	r := mustResampler(t, 2, 44100, 176400)
+	out := r.UpsampleHalfBandCascade(append(in, 0.5), 2)
+	if len(out) != 4*len(in) {
+		t.Fatalf("got %d samples, want %d", len(out), 4*len(in))
+	}
+	// The input samples are kept, at every 4th frame.
+	for f := 0; f < len(in)/2; f++ {
+		for c := 0; c < 2; c++ {
+			if out[8*f+c] != in[2*f+c] {
+				t.Fatalf("frame %d, channel %d: got %v, want the input %v", f, c, out[8*f+c], in[2*f+c])
+			}
+		}
+	}
+
+	signal, _ := tone(out[8000:len(out)-8000], 2, 0, 5000, 176400)
+	image, _ := tone(out[8000:len(out)-8000], 2, 0, 44100-5000, 176400)
+	if db := 20 * math.Log10(image/signal); db > -70 {
+		t.Errorf("the image of the first stage is at %.1f dB, want below -70 dB", db)
+	}
+
+	if out := r.UpsampleHalfBandCascade(in, 0); &out[0] != &in[0] {
+		t.Error("0 stages should return the input itself")
+	}
+}
+
+// Compares 4x upsampling by two half-band stages against the generic
+// interpolators at the same ratio.
+func BenchmarkUpsampleHalfBandCascade(b *testing.B) {
+	in := sine(1<<16, 2, 440, 44100)
+	b.Run("HalfBand", func(b *testing.B) {
+		r := mustResampler(b, 2, 44100, 176400)
+		b.SetBytes(int64(8 * len(in)))
+		for i := 0; i < b.N; i++ {
+			r.UpsampleHalfBandCascade(in, 2)
+		}
+	})
+	for _, bm := range []struct {
+		name string
+		mode InterpMode
+	}{{"Spline", InterpSpline}, {"Sinc", InterpSinc}} {
+		r := mustResampler(b, 2, 44100, 176400, WithInterpolation(bm.mode))
+		b.Run(bm.name, func(b *testing.B) {
+			b.SetBytes(int64(8 * len(in)))
+			for i := 0; i < b.N; i++ {
+				r.ResampleFloat64(in)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import "fmt"
+
+// Splits an interleaved buffer into one slice per channel. Returns an error if
+// channels is less than 1 or data does not hold a whole amount of frames.
+func Deinterleave(data []float64, channels int) ([][]float64, error) {
+	if channels < 1 {
+		return nil, fmt.Errorf("at least 1 channel is required (have %d)", channels)
+	}
+	if len(data)%channels != 0 {
+		return nil, fmt.Errorf("buffer of %d samples is not a whole amount of %d-channel frames", len(data), channels)
+	}
+	return splitInto(make([][]float64, channels), data), nil
+}
+
+// Interleaves one slice per channel into a single buffer, the inverse of
+// Deinterleave. Returns an error if there are no channels or they differ in
+// length.
+func Interleave(channels [][]float64) ([]float64, error) {
+	if len(channels) == 0 {
+		return nil, fmt.Errorf("at least 1 channel is required (have 0)")
+	}
+	for c, data := range channels {
+		if len(data) != len(channels[0]) {
+			return nil, fmt.Errorf("channel %d has %d samples, but channel 0 has %d", c, len(data), len(channels[0]))
+		}
+	}
+	return interleave(channels), nil
+}
+
+// Interleaves channels of equal length.
+func interleave(channels [][]float64) []float64 {
+	if len(channels) == 0 {
+		return nil
+	}
+	out := make([]float64, len(channels[0])*len(channels))
+	for i := range out {
+		out[i] = channels[i%len(channels)][i/len(channels)]
+	}
+	return out
+}
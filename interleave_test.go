@@ -0,0 +1,75 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import "testing"
+
+func TestDeinterleave(t *testing.T) {
+	in := []float64{1, 2, 3, 4, 5, 6}
+	channels, err := Deinterleave(in, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for c, want := range [][]float64{{1, 4}, {2, 5}, {3, 6}} {
+		assertEqualSamples(t, channels[c], want)
+	}
+	out, err := Interleave(channels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqualSamples(t, out, in)
+
+	if _, err := Deinterleave(in, 0); err == nil {
+		t.Error("expected an error for 0 channels")
+	}
+	if _, err := Deinterleave(in, 4); err == nil {
+		t.Error("expected an error for a partial frame")
+	}
+	if _, err := Interleave(nil); err == nil {
+		t.Error("expected an error for no channels")
+	}
+	if _, err := Interleave([][]float64{{1, 2}, {3}}); err == nil {
+		t.Error("expected an error for channels of different lengths")
+	}
+}
+
+func TestSplitInto(t *testing.T) {
+	// A frame split between the segments, and a trailing partial frame.
+	channels := splitInto(make([][]float64, 3), []float64{1, 2, 3, 4}, []float64{5, 6, 7, 8})
+	for c, want := range [][]float64{{1, 4, 7}, {2, 5, 8}, {3, 6}} {
+		assertEqualSamples(t, channels[c], want)
+	}
+
+	in := sine(1000, 2, 440, 44100)
+	if allocs := testing.AllocsPerRun(10, func() { splitInto(make([][]float64, 2), in) }); allocs > 3 {
+		t.Errorf("splitting 2 channels made %v allocations, want one per channel and one for the slice headers", allocs)
+	}
+	reused := make([][]float64, 2)
+	splitInto(reused, in)
+	if allocs := testing.AllocsPerRun(10, func() { splitInto(reused, in) }); allocs != 0 {
+		t.Errorf("splitting into channels with enough capacity made %v allocations", allocs)
+	}
+}
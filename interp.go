@@ -0,0 +1,264 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import (
+	"fmt"
+	"math"
+)
+
+// How output samples are interpolated from the input samples around them.
+type InterpMode int
+
+const (
+	InterpSpline InterpMode = iota // Natural cubic spline through 4 samples (the default).
+	InterpLinear                   // A straight line between the 2 nearest samples.
+	InterpSinc                     // Windowed sinc, band-limited to the lower Nyquist frequency.
+)
+
+// The window that tapers the sinc kernel of InterpSinc.
+type SincWindow int
+
+const (
+	SincBlackman       SincWindow = iota // Blackman, with sidelobes near -58 dB (the default).
+	SincBlackmanHarris                   // 4-term Blackman-Harris, with sidelobes near -92 dB but a wider transition band.
+)
+
+// Selects the interpolation mode. The default is InterpSpline.
+func WithInterpolation(mode InterpMode) Option {
+	return func(r *Resampler) error {
+		if mode != InterpSpline && mode != InterpLinear && mode != InterpSinc {
+			return fmt.Errorf("unknown interpolation mode %d", mode)
+		}
+		r.mode = mode
+		return nil
+	}
+}
+
+// Selects the window of the sinc kernel used by InterpSinc. The default is
+// SincBlackman.
+func WithSincWindow(window SincWindow) Option {
+	return func(r *Resampler) error {
+		if window != SincBlackman && window != SincBlackmanHarris {
+			return fmt.Errorf("unknown sinc window %d", window)
+		}
+		r.sincWindow = window
+		return nil
+	}
+}
+
+// Resamples a float64 audio buffer like ResampleFloat64, but interpolating in
+// mode for this call only; the mode of the resampler is left as it is. Returns
+// nil if mode is unknown.
+func (r *Resampler) ResampleFloat64Mode(data []float64, mode InterpMode) []float64 {
+	if mode != InterpSpline && mode != InterpLinear && mode != InterpSinc {
+		return nil
+	}
+	target := *r
+	target.mode = mode
+	return target.ResampleFloat64(data)
+}
+
+// Computes output samples from a window of input samples around the read
+// position.
+type interpolator interface {
+	// Returns how many samples the window holds before the sample at the
+	// integer part of the read position, and how many it holds in total.
+	window() (before, size int)
+
+	// Interpolates the value at frac (0 <= frac < 1) past yi[before].
+	interpolate(yi []float64, frac float64) float64
+}
+
+// Returns the interpolator of the configured mode.
+func (r *Resampler) interpolator() interpolator {
+	switch r.mode {
+	case InterpLinear:
+		return linearInterpolator{}
+	case InterpSinc:
+		s := newSincInterpolator(float64(r.FromRate), float64(r.ToRate))
+		s.taper = r.sincWindow
+		return s
+	}
+	return splineInterpolator{}
+}
+
+// Returns the interpolator of the configured mode for a channel of n samples.
+// A sinc kernel is made no wider than the channel, since the samples past its
+// ends only repeat the edge samples, so long kernels of extreme downsampling
+// ratios cannot blow up the window of short inputs.
+func (r *Resampler) interpolatorFor(n int) interpolator {
+	interp := r.interpolator()
+	if s, ok := interp.(sincInterpolator); ok && s.half > n {
+		s.half = n
+		if s.half < 1 {
+			s.half = 1
+		}
+		return s
+	}
+	return interp
+}
+
+type splineInterpolator struct{}
+
+func (splineInterpolator) window() (before, size int) {
+	return 0, 4
+}
+
+func (splineInterpolator) interpolate(yi []float64, frac float64) float64 {
+	return spline(0, yi, frac)
+}
+
+type linearInterpolator struct{}
+
+func (linearInterpolator) window() (before, size int) {
+	return 0, 2
+}
+
+func (linearInterpolator) interpolate(yi []float64, frac float64) float64 {
+	return yi[0] + frac*(yi[1]-yi[0])
+}
+
+// Zero crossings of the sinc kernel on each side of the read position.
+const sincZeroCrossings = 16
+
+type sincInterpolator struct {
+	cutoff float64    // Cutoff as a fraction of the input Nyquist frequency.
+	half   int        // Samples on each side of the read position.
+	taper  SincWindow // Window applied to the kernel.
+}
+
+// Creates a sinc interpolator that keeps everything below the Nyquist frequency
+// of the lower of the two rates. When downsampling, the kernel is widened so it
+// still spans sincZeroCrossings zero crossings on each side.
+func newSincInterpolator(fromRate, toRate float64) sincInterpolator {
+	cutoff := math.Min(1, toRate/fromRate)
+	return sincInterpolator{
+		cutoff: cutoff,
+		half:   int(math.Ceil(sincZeroCrossings / cutoff)),
+	}
+}
+
+func (s sincInterpolator) window() (before, size int) {
+	return s.half - 1, 2 * s.half
+}
+
+func (s sincInterpolator) interpolate(yi []float64, frac float64) float64 {
+	var sum, weights float64
+	for j, y := range yi {
+		t := float64(j-(s.half-1)) - frac
+		w := s.windowAt(t)
+		if t != 0 {
+			w *= math.Sin(math.Pi*s.cutoff*t) / (math.Pi * s.cutoff * t)
+		}
+		sum += w * y
+		weights += w
+	}
+	// Normalize to unity DC gain
+	return sum / weights
+}
+
+// Returns the window at t samples from the read position, from 1 at the centre
+// down to 0 at half samples away.
+func (s sincInterpolator) windowAt(t float64) float64 {
+	x := math.Pi * t / float64(s.half)
+	if s.taper == SincBlackmanHarris {
+		return 0.35875 + 0.48829*math.Cos(x) + 0.14128*math.Cos(2*x) + 0.01168*math.Cos(3*x)
+	}
+	return 0.42 + 0.5*math.Cos(x) + 0.08*math.Cos(2*x)
+}
+
+// Returns the cheapest interpolation mode that attenuates the images and
+// aliases of content below a quarter of the lower of the two rates by at least
+// minStopbandDB, when resampling by ratio (the output rate over the input rate)
+// without AntiAlias, and how many input samples its kernel reads per output
+// sample. The modes cost more from InterpLinear to InterpSpline to InterpSinc.
+// Returns InterpSinc and 0 taps if no mode reaches minStopbandDB or ratio is not
+// a positive number. The kernels are measured on every call, which takes tens
+// of milliseconds, so pick the mode once per configuration.
+func ModeForAttenuation(ratio float64, minStopbandDB float64) (mode InterpMode, taps int) {
+	if !(ratio > 0) || ratio > MaxSampleRate {
+		return InterpSinc, 0
+	}
+	lower := math.Min(1, ratio)
+	if attenuationDB(linearInterpolator{}, lower) >= minStopbandDB {
+		return InterpLinear, 2
+	}
+	if attenuationDB(splineInterpolator{}, lower) >= minStopbandDB {
+		return InterpSpline, 4
+	}
+	// Past decimation by 32 the sinc kernel only stretches further, keeping its
+	// response, so it is measured there, where it is still short
+	measured := math.Max(lower, 1.0/32)
+	if attenuationDB(newSincInterpolator(1, measured), measured) >= minStopbandDB {
+		_, taps = newSincInterpolator(1, ratio).window()
+		return InterpSinc, taps
+	}
+	return InterpSinc, 0
+}
+
+// Returns how many dB interp attenuates the frequencies from 0.75*lower to
+// 4.75*lower, which image or alias into the band below lower/4 (the nearest
+// images and aliases being the strongest), relative to that band. Frequencies
+// are in cycles per input sample, and lower is the lower of the two rates in the
+// same unit. The response is that of the continuous kernel, sampled often enough
+// per input sample to cover those frequencies; wide sinc kernels only pass low
+// frequencies, so they are sampled less often, keeping about a thousand points.
+func attenuationDB(interp interpolator, lower float64) float64 {
+	_, size := interp.window()
+	oversample := 32
+	if size > 32 {
+		oversample = int(math.Ceil(32 * 32 / float64(size)))
+	}
+	kernel := make([]float64, size*oversample)
+	impulse := make([]float64, size)
+	for j := range impulse {
+		impulse[j] = 1
+		for k := 0; k < oversample; k++ {
+			kernel[(size-1-j)*oversample+k] = interp.interpolate(impulse, float64(k)/float64(oversample))
+		}
+		impulse[j] = 0
+	}
+	response := func(f float64) float64 {
+		var re, im float64
+		for i, h := range kernel {
+			w := 2 * math.Pi * f * float64(i) / float64(oversample)
+			re += h * math.Cos(w)
+			im += h * math.Sin(w)
+		}
+		return math.Hypot(re, im)
+	}
+
+	pass := math.Inf(1)
+	for i := 0; i <= 200; i++ {
+		pass = math.Min(pass, response(float64(i)*lower/4/200))
+	}
+	stop := 0.0
+	for i := 0; i <= 2000; i++ {
+		stop = math.Max(stop, response(0.75*lower+float64(i)*4*lower/2000))
+	}
+	return 20 * math.Log10(pass/stop)
+}
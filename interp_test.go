@@ -0,0 +1,276 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import (
+	"math"
+	"testing"
+)
+
+// The interpolation mode is part of the resampler configuration; a resampler
+// keeps using the mode it was created with.
+func TestInterpolationModeIsPerResampler(t *testing.T) {
+	in := sine(1000, 1, 440, 44100)
+	want := mustResampler(t, 1, 44100, 48000, WithInterpolation(InterpSpline)).ResampleFloat64(in)
+	def := mustResampler(t, 1, 44100, 48000)
+	assertEqualSamples(t, def.ResampleFloat64(in), want)
+
+	linear := mustResampler(t, 1, 44100, 48000, WithInterpolation(InterpLinear))
+	first := linear.ResampleFloat64(in)
+	assertEqualSamples(t, linear.ResampleFloat64(in), first)
+	assertEqualSamples(t, linear.Clone().ResampleFloat64(in), first)
+}
+
+// ResampleFloat64Mode switches the mode for one call, giving what a resampler
+// created with that mode gives, and leaves the configured mode alone.
+func TestResampleFloat64Mode(t *testing.T) {
+	in := sine(1000, 2, 440, 44100)
+	r := mustResampler(t, 2, 44100, 48000, WithInterpolation(InterpSinc))
+	sinc := r.ResampleFloat64(in)
+	for _, mode := range []InterpMode{InterpLinear, InterpSpline} {
+		want := mustResampler(t, 2, 44100, 48000, WithInterpolation(mode)).ResampleFloat64(in)
+		assertEqualSamples(t, r.ResampleFloat64Mode(in, mode), want)
+	}
+	assertEqualSamples(t, r.ResampleFloat64(in), sinc)
+	if out := r.ResampleFloat64Mode(in, 99); out != nil {
+		t.Errorf("got %d samples for an unknown mode, want nil", len(out))
+	}
+}
+
+// Interpolation has no filter state and delays nothing: a symmetric pulse
+// comes out centred on the same instant, and exactly symmetric for the linear
+// and sinc kernels (the spline only looks ahead, so it is slightly skewed).
+func TestInterpolationHasNoDelay(t *testing.T) {
+	in := make([]float64, 201)
+	for i := range in {
+		x := float64(i-100) / 6
+		in[i] = math.Exp(-x * x)
+	}
+	for _, mode := range []InterpMode{InterpLinear, InterpSpline, InterpSinc} {
+		out := mustResampler(t, 1, 1000, 3000, WithInterpolation(mode)).ResampleFloat64(in)
+		var moment, sum float64
+		for i, v := range out {
+			moment += float64(i) * v
+			sum += v
+		}
+		if centre := moment / sum; math.Abs(centre-300) > 1e-9 {
+			t.Errorf("mode %d: the pulse is centred on output sample %v, want 300", mode, centre)
+		}
+		if mode == InterpSpline {
+			continue
+		}
+		for j := 1; j < 150; j++ {
+			if d := math.Abs(out[300+j] - out[300-j]); d > 1e-12 {
+				t.Fatalf("mode %d: samples %d and %d around the peak differ by %v", mode, 300-j, 300+j, d)
+			}
+		}
+	}
+}
+
+// The modes trade speed for stopband attenuation in a fixed order, cheapest
+// first: the images left by upsampling get smaller from linear to spline to
+// sinc.
+func TestInterpolationModeAttenuation(t *testing.T) {
+	in := sine(8000, 1, 1000, 8000)
+	last := math.Inf(1)
+	for _, mode := range []InterpMode{InterpLinear, InterpSpline, InterpSinc} {
+		out := mustResampler(t, 1, 8000, 48000, WithInterpolation(mode)).ResampleFloat64(in)
+		signal, _ := tone(out[4800:43200], 1, 0, 1000, 48000)
+		image, _ := tone(out[4800:43200], 1, 0, 7000, 48000)
+		db := 20 * math.Log10(image/signal)
+		if db >= last {
+			t.Errorf("mode %d leaves an image at %.1f dB, no better than the cheaper mode (%.1f dB)", mode, db, last)
+		}
+		last = db
+	}
+	if last > -80 {
+		t.Errorf("sinc leaves an image at %.1f dB, want below -80 dB", last)
+	}
+}
+
+// Lenient targets get the cheap kernels, strict ones the sinc, with as many taps
+// as it reads at that ratio. When downsampling only the sinc removes aliases.
+func TestModeForAttenuation(t *testing.T) {
+	for _, c := range []struct {
+		ratio float64
+		db    float64
+		mode  InterpMode
+		taps  int
+	}{
+		{6, 15, InterpLinear, 2},
+		{6, 20, InterpSpline, 4},
+		{6, 60, InterpSinc, 32},
+		{1.0 / 6, 15, InterpSinc, 192},
+		{1.0 / 6, 60, InterpSinc, 192},
+		{6, 200, InterpSinc, 0},
+		{0, 15, InterpSinc, 0},
+	} {
+		if mode, taps := ModeForAttenuation(c.ratio, c.db); mode != c.mode || taps != c.taps {
+			t.Errorf("ratio %v, %v dB: got mode %d with %d taps, want mode %d with %d taps", c.ratio, c.db, mode, taps, c.mode, c.taps)
+		}
+	}
+
+	// The sinc picked for 60 dB keeps the aliases of decimating by 6 below that.
+	mode, _ := ModeForAttenuation(8000.0/48000, 60)
+	r := mustResampler(t, 1, 48000, 8000, WithInterpolation(mode))
+	for _, f := range []float64{6500, 7000, 9000} {
+		out := r.ResampleFloat64(sine(144000, 1, f, 48000))
+		alias, _ := tone(out[8000:16000], 1, 0, math.Abs(8000-f), 8000)
+		if db := 20 * math.Log10(alias/0.8); db > -60 {
+			t.Errorf("%v Hz aliases at %.1f dB", f, db)
+		}
+	}
+}
+
+// When upsampling, the Blackman-windowed sinc keeps its zero crossings on the
+// input samples, so output samples that land on one reproduce it.
+func TestSincPassesThroughSamples(t *testing.T) {
+	in := sine(500, 1, 3000, 8000)
+	out := mustResampler(t, 1, 8000, 24000, WithInterpolation(InterpSinc)).ResampleFloat64(in)
+	for i, v := range in {
+		if math.Abs(out[3*i]-v) > 1e-12 {
+			t.Fatalf("output sample %d is %v, want input sample %d (%v)", 3*i, out[3*i], i, v)
+		}
+	}
+}
+
+// Invalid mode settings are rejected when the resampler is created, not when
+// it is first used.
+func TestWithInterpolationRejectsUnknownModes(t *testing.T) {
+	for _, mode := range []InterpMode{-1, 3, 99} {
+		if r, err := NewResampler(1, 44100, 48000, WithInterpolation(mode)); err == nil || r != nil {
+			t.Errorf("mode %d: expected an error and no resampler", mode)
+		}
+	}
+}
+
+// Measures the peak magnitude response in dB of a sinc kernel read halfway
+// between two samples, from fMin up to the Nyquist frequency, in cycles per
+// input sample.
+func sincStopbandPeakDB(s sincInterpolator, fMin float64) float64 {
+	_, size := s.window()
+	taps := make([]float64, size)
+	for j := range taps {
+		impulse := make([]float64, size)
+		impulse[j] = 1
+		taps[j] = s.interpolate(impulse, 0.5)
+	}
+	peak := 0.0
+	for f := fMin; f <= 0.5; f += 0.0005 {
+		var re, im float64
+		for j, tap := range taps {
+			re += tap * math.Cos(2*math.Pi*f*float64(j))
+			im += tap * math.Sin(2*math.Pi*f*float64(j))
+		}
+		peak = math.Max(peak, math.Hypot(re, im))
+	}
+	return 20 * math.Log10(peak)
+}
+
+// With the same taps, the Blackman-Harris kernel leaks far less into the
+// stopband than the Blackman one, and both keep unity DC gain.
+func TestSincBlackmanHarris(t *testing.T) {
+	blackman := newSincInterpolator(2, 1)
+	harris := blackman
+	harris.taper = SincBlackmanHarris
+	// Halving the rate cuts off at 0.25 cycles per sample; 0.32 is past the
+	// transition band of both windows
+	b, h := sincStopbandPeakDB(blackman, 0.32), sincStopbandPeakDB(harris, 0.32)
+	if h > b-15 {
+		t.Errorf("Blackman-Harris stopband peaks at %.1f dB, Blackman at %.1f dB", h, b)
+	}
+
+	r := mustResampler(t, 2, 44100, 16000, WithInterpolation(InterpSinc), WithSincWindow(SincBlackmanHarris))
+	for i, v := range r.ResampleFloat64(constant(2000, 0.5)) {
+		if math.Abs(v-0.5) > 1e-12 {
+			t.Fatalf("sample %d of a constant is %v, want 0.5", i, v)
+		}
+	}
+	if _, err := NewResampler(1, 44100, 48000, WithSincWindow(2)); err == nil {
+		t.Error("expected an error for an unknown sinc window")
+	}
+}
+
+func TestInterpolationModes(t *testing.T) {
+	linear := mustResampler(t, 1, 2, 3, WithInterpolation(InterpLinear))
+	want := []float64{0, 2, 4, 6, 4, 3}
+	assertEqualSamples(t, linear.ResampleFloat64([]float64{0, 3, 6, 3}), want)
+
+	in := sine(5001, 2, 440, 8000)
+	for _, mode := range []InterpMode{InterpLinear, InterpSpline, InterpSinc} {
+		for _, rates := range [][2]int{{44100, 48000}, {48000, 8000}, {8000, 44100}} {
+			r := mustResampler(t, 2, rates[0], rates[1], WithInterpolation(mode))
+			var blocks []float64
+			r.ResampleFloat64Blocks(in, 77, func(block []float64) { blocks = append(blocks, block...) })
+			assertEqualSamples(t, blocks, r.ResampleFloat64(in))
+		}
+	}
+}
+
+// The sinc kernel of an extreme ratio is wider than a short input; it is
+// capped to the input instead of growing the window.
+func TestSincShortInput(t *testing.T) {
+	r := mustResampler(t, 1, 48000, 1, WithInterpolation(InterpSinc))
+	if half := newSincInterpolator(48000, 1).half; half < 1000 {
+		t.Fatalf("expected a wide kernel, got %d samples per side", half)
+	}
+	for _, n := range []int{1, 5, 48000} {
+		out := r.ResampleFloat64(constant(n, 0.25))
+		if len(out) != r.OutputLen(n) {
+			t.Fatalf("%d samples: got %d samples, want %d", n, len(out), r.OutputLen(n))
+		}
+		for i, v := range out {
+			if math.Abs(v-0.25) > 1e-12 {
+				t.Fatalf("%d samples: sample %d is %v, want 0.25", n, i, v)
+			}
+		}
+	}
+}
+
+func TestWindowIsClampedAtTheEnds(t *testing.T) {
+	for _, mode := range []InterpMode{InterpLinear, InterpSpline, InterpSinc} {
+		for _, rates := range [][2]int{{1, 7}, {3, 4}, {4, 3}, {7, 1}} {
+			r := mustResampler(t, 1, rates[0], rates[1], WithInterpolation(mode))
+			for n := 1; n <= 8; n++ {
+				// The windows repeat the first and last sample past the ends.
+				for i, v := range r.ResampleFloat64(constant(n, -0.5)) {
+					if math.Abs(v+0.5) > 1e-12 {
+						t.Fatalf("mode %d, %d Hz to %d Hz, %d samples: sample %d is %v, want -0.5", mode, rates[0], rates[1], n, i, v)
+					}
+				}
+			}
+		}
+	}
+
+	// The last input sample is held for the positions after it.
+	r := mustResampler(t, 1, 1, 4, WithInterpolation(InterpLinear))
+	out := r.ResampleFloat64([]float64{1, 2, 3})
+	for k := 8; k < len(out); k++ {
+		if out[k] != 3 {
+			t.Errorf("sample %d, read at %v, is %v, want 3", k, r.ReadPosition(k), out[k])
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+//go:build gomplerate_invariants
+
+package gomplerate
+
+import "testing"
+
+// Runs every resample stage over many rates, channel counts and lengths, so
+// the invariant checks of this build panic on any length mismatch.
+func TestInvariants(t *testing.T) {
+	rates := []int{1, 3, 7, 8000, 11025, 16000, 22050, 44100, 48000, 88200, 96000, 192000}
+	for _, from := range rates {
+		for _, to := range rates {
+			if from == to || to > 40*from || from > 40*to {
+				continue
+			}
+			for channels := 1; channels <= 3; channels++ {
+				for _, low := range []bool{false, true} {
+					r := mustResampler(t, channels, from, to, WithLowMemory(low))
+					for n := 1; n < 60; n++ {
+						data := make([]float64, n)
+						if out := r.ResampleFloat64(data); len(out) != r.OutputLen(n) {
+							t.Fatalf("%d Hz to %d Hz, %d channels: %d samples became %d, want %d", from, to, channels, n, len(out), r.OutputLen(n))
+						}
+						if _, err := r.ResampleFloat64Into(make([]float64, r.OutputLen(n)), data); err != nil {
+							t.Fatal(err)
+						}
+						r.ResampleRing(data[:n/2], data[n/2:])
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestCheckLengthsPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for mismatched lengths")
+		}
+	}()
+	checkLengths("test", 4, 4, 3)
+}
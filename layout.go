@@ -0,0 +1,113 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// The speaker position a channel is meant for.
+type Channel int
+
+const (
+	ChannelFL  Channel = iota // Front left.
+	ChannelFR                 // Front right.
+	ChannelC                  // Center.
+	ChannelLFE                // Low frequency effects.
+	ChannelSL                 // Surround left.
+	ChannelSR                 // Surround right.
+)
+
+// Names the speaker position of every interleaved channel, in order.
+type ChannelLayout []Channel
+
+var (
+	LayoutMono   = ChannelLayout{ChannelC}
+	LayoutStereo = ChannelLayout{ChannelFL, ChannelFR}
+	Layout51     = ChannelLayout{ChannelFL, ChannelFR, ChannelC, ChannelLFE, ChannelSL, ChannelSR}
+)
+
+// Describes what each channel of the input is. The layout must name exactly as
+// many channels as the resampler has. Required by ResampleDownmixStereo.
+func WithChannelLayout(layout ChannelLayout) Option {
+	return func(r *Resampler) error {
+		if len(layout) != r.Channels {
+			return fmt.Errorf("channel layout has %d channels, but the resampler has %d", len(layout), r.Channels)
+		}
+		r.layout = layout
+		return nil
+	}
+}
+
+// Downmixes a float64 audio buffer to stereo using the channel layout and the
+// ITU-R BS.775 coefficients, then resamples it. Center and surround channels are
+// mixed in at -3 dB and the LFE channel is dropped. The mix is not normalized,
+// so loud multichannel input may exceed [-1, 1]. Returns the resampled,
+// interleaved stereo buffer.
+func (r *Resampler) ResampleDownmixStereo(data []float64) ([]float64, error) {
+	if r.layout == nil {
+		return nil, errors.New("downmixing requires a channel layout (see WithChannelLayout)")
+	}
+
+	// Gain of every input channel in the left and right output
+	left := make([]float64, len(r.layout))
+	right := make([]float64, len(r.layout))
+	attenuated := 1 / math.Sqrt2
+	for i, ch := range r.layout {
+		switch ch {
+		case ChannelFL:
+			left[i] = 1
+		case ChannelFR:
+			right[i] = 1
+		case ChannelC:
+			left[i], right[i] = attenuated, attenuated
+		case ChannelSL:
+			left[i] = attenuated
+		case ChannelSR:
+			right[i] = attenuated
+		}
+	}
+
+	target := *r
+	target.Channels = 2
+	target.layout = LayoutStereo
+	frames := len(data) / r.Channels
+	if target.outputTooLarge(frames * 2) {
+		return nil, ErrOutputTooLarge
+	}
+
+	stereo := make([]float64, frames*2)
+	for f := 0; f < frames; f++ {
+		frame := data[f*r.Channels : (f+1)*r.Channels]
+		for i, v := range frame {
+			stereo[f*2] += v * left[i]
+			stereo[f*2+1] += v * right[i]
+		}
+	}
+	return target.ResampleFloat64(stereo), nil
+}
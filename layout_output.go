@@ -0,0 +1,96 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import "fmt"
+
+// How ResampleFloat64Layout returns the channels of its result.
+type OutputLayout int
+
+const (
+	Interleaved OutputLayout = iota // A single []float64 with interleaved frames.
+	Planar                          // A [][]float64 with one slice per channel.
+)
+
+// Sets the layout ResampleFloat64Layout returns its result in. The default is
+// Interleaved.
+func WithOutputLayout(layout OutputLayout) Option {
+	return func(r *Resampler) error {
+		if layout != Interleaved && layout != Planar {
+			return fmt.Errorf("unknown output layout %d", layout)
+		}
+		r.outputLayout = layout
+		return nil
+	}
+}
+
+// Resamples an interleaved float64 audio buffer and returns the result in the
+// configured OutputLayout: a []float64 for Interleaved or a [][]float64 for
+// Planar. Planar output skips interleaving the channels again.
+func (r *Resampler) ResampleFloat64Layout(data []float64) interface{} {
+	if r.outputLayout == Interleaved {
+		return r.ResampleFloat64(data)
+	}
+	return r.resampleFloat64Planar(data)
+}
+
+// Resamples an interleaved float64 audio buffer and returns one slice per
+// channel, without ever interleaving the result. It is the typed form of
+// ResampleFloat64Layout with the Planar layout, for consumers that read one
+// channel at a time.
+func (r *Resampler) ResampleFloat64Channels(data []float64) [][]float64 {
+	return r.resampleFloat64Planar(data)
+}
+
+// Resamples an interleaved stereo float64 audio buffer and returns the left and
+// right channels of the result as separate slices. Returns nil slices if the
+// resampler does not have exactly 2 channels.
+func (r *Resampler) ResampleStereo(data []float64) (left, right []float64) {
+	if r.Channels != 2 {
+		return nil, nil
+	}
+	channels := r.resampleFloat64Planar(data)
+	if channels == nil {
+		return nil, nil
+	}
+	return channels[0], channels[1]
+}
+
+// Resamples an interleaved float64 audio buffer into one slice per channel.
+func (r *Resampler) resampleFloat64Planar(data []float64) [][]float64 {
+	if len(data) == 0 || r.outputTooLarge(len(data)) {
+		return nil
+	}
+	if r.FromRate == r.ToRate {
+		channels := r.splitChannels(data)
+		for _, channel := range channels {
+			r.applyGain(channel)
+		}
+		return channels
+	}
+	channels := r.splitChannelsScratch(data)
+	return r.resamplePlanar(channels, r.OutputLen(len(data))/r.Channels)
+}
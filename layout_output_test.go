@@ -0,0 +1,106 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import "testing"
+
+// Checks that channels holds the frames of interleaved.
+func assertPlanar(t testing.TB, channels [][]float64, interleaved []float64) {
+	t.Helper()
+	if len(interleaved) == 0 {
+		if channels != nil {
+			t.Fatalf("got %d channels for no output", len(channels))
+		}
+		return
+	}
+	for c, channel := range channels {
+		if len(channel)*len(channels) != len(interleaved) {
+			t.Fatalf("channel %d holds %d frames, want %d", c, len(channel), len(interleaved)/len(channels))
+		}
+		for i, v := range channel {
+			if want := interleaved[i*len(channels)+c]; v != want {
+				t.Fatalf("channel %d, frame %d: got %v, want %v", c, i, v, want)
+			}
+		}
+	}
+}
+
+func TestWithOutputLayout(t *testing.T) {
+	for _, to := range []int{48000, 44100} {
+		for _, n := range []int{0, 5, 100, 1001, 1003} {
+			in := sine(n, 3, 300, 44100)
+			interleaved := mustResampler(t, 3, 44100, to).ResampleFloat64Layout(in).([]float64)
+			planar := mustResampler(t, 3, 44100, to, WithOutputLayout(Planar)).ResampleFloat64Layout(in).([][]float64)
+			if len(interleaved) > 0 && len(planar) != 3 {
+				t.Fatalf("got %d channels, want 3", len(planar))
+			}
+			assertPlanar(t, planar, interleaved)
+		}
+	}
+	if _, err := NewResampler(1, 44100, 48000, WithOutputLayout(OutputLayout(7))); err == nil {
+		t.Error("expected an error for an unknown layout")
+	}
+}
+
+func TestResampleStereo(t *testing.T) {
+	in := sine(1001, 2, 440, 44100)
+	r := mustResampler(t, 2, 44100, 48000)
+	left, right := r.ResampleStereo(in)
+	assertPlanar(t, [][]float64{left, right}, r.ResampleFloat64(in))
+
+	if left, right := mustResampler(t, 3, 44100, 48000).ResampleStereo(sine(100, 3, 440, 44100)); left != nil || right != nil {
+		t.Error("expected nil channels for a resampler without 2 channels")
+	}
+}
+
+func TestResampleFloat64Channels(t *testing.T) {
+	for _, to := range []int{48000, 44100} {
+		in := sine(1001, 3, 440, 44100)
+		r := mustResampler(t, 3, 44100, to, WithGain(0.5))
+		assertPlanar(t, r.ResampleFloat64Channels(in), r.ResampleFloat64(in))
+	}
+}
+
+// Compares returning the resampled channels as they are against interleaving
+// them, on 8 channels.
+func BenchmarkResampleFloat64Channels(b *testing.B) {
+	in := sine(1<<16, 8, 440, 44100)
+	r := mustResampler(b, 8, 44100, 48000)
+	b.Run("Channels", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(8 * len(in)))
+		for i := 0; i < b.N; i++ {
+			r.ResampleFloat64Channels(in)
+		}
+	})
+	b.Run("Interleaved", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(8 * len(in)))
+		for i := 0; i < b.N; i++ {
+			r.ResampleFloat64(in)
+		}
+	})
+}
@@ -0,0 +1,65 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import (
+	"math"
+	"testing"
+)
+
+func TestResampleDownmixStereo(t *testing.T) {
+	r := mustResampler(t, 6, 48000, 44100, WithChannelLayout(Layout51))
+	levels := []float64{0.1, 0.2, 0.5, 0.9, 0.3, 0.4}
+	in := make([]float64, 6*200)
+	for i := range in {
+		in[i] = levels[i%6]
+	}
+	out, err := r.ResampleDownmixStereo(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := mustResampler(t, 2, 48000, 44100).OutputLen(2 * 200); len(out) != want {
+		t.Fatalf("got %d samples, want %d", len(out), want)
+	}
+	// FL + C and SL at -3 dB, LFE dropped.
+	left := 0.1 + (0.5+0.3)/math.Sqrt2
+	right := 0.2 + (0.5+0.4)/math.Sqrt2
+	for f := 0; f < len(out)/2; f++ {
+		if math.Abs(out[2*f]-left) > 1e-12 || math.Abs(out[2*f+1]-right) > 1e-12 {
+			t.Fatalf("frame %d: got %v, %v, want %v, %v", f, out[2*f], out[2*f+1], left, right)
+		}
+	}
+}
+
+func TestChannelLayoutErrors(t *testing.T) {
+	if _, err := NewResampler(2, 48000, 44100, WithChannelLayout(Layout51)); err == nil {
+		t.Error("expected an error for a layout with the wrong channel count")
+	}
+	r := mustResampler(t, 2, 48000, 44100)
+	if _, err := r.ResampleDownmixStereo(sine(10, 2, 440, 48000)); err == nil {
+		t.Error("expected an error for downmixing without a layout")
+	}
+}
@@ -0,0 +1,153 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import "math"
+
+// Measures the integrated loudness of an interleaved buffer in LUFS, following a
+// simplified ITU-R BS.1770: the signal is K-weighted, split into 400 ms blocks
+// with 75% overlap and gated at -70 LUFS and then 10 LU below the loudness of
+// the remaining blocks. All channels are weighted equally. Returns -Inf for
+// silence or a buffer shorter than one block.
+func Loudness(data []float64, channels, sampleRate int) float64 {
+	if channels < 1 || sampleRate < 1 {
+		return math.Inf(-1)
+	}
+	frames := len(data) / channels
+	blockLen := sampleRate * 4 / 10
+	hop := blockLen / 4
+	if frames < blockLen || hop < 1 {
+		return math.Inf(-1)
+	}
+
+	// K-weighted energy of every frame, summed over channels
+	energy := make([]float64, frames)
+	for c := 0; c < channels; c++ {
+		shelf, highPass := kWeighting(float64(sampleRate))
+		for f := 0; f < frames; f++ {
+			v := highPass.process(shelf.process(data[f*channels+c]))
+			energy[f] += v * v
+		}
+	}
+
+	var blocks []float64
+	for start := 0; start+blockLen <= frames; start += hop {
+		sum := 0.0
+		for _, e := range energy[start : start+blockLen] {
+			sum += e
+		}
+		blocks = append(blocks, sum/float64(blockLen))
+	}
+
+	gated := func(threshold float64) float64 {
+		sum, n := 0.0, 0
+		for _, z := range blocks {
+			if blockLoudness(z) > threshold {
+				sum += z
+				n++
+			}
+		}
+		if n == 0 {
+			return 0
+		}
+		return sum / float64(n)
+	}
+	absolute := gated(-70)
+	if absolute == 0 {
+		return math.Inf(-1)
+	}
+	relative := gated(blockLoudness(absolute) - 10)
+	if relative == 0 {
+		return math.Inf(-1)
+	}
+	return blockLoudness(relative)
+}
+
+// Scales an interleaved buffer in place so its Loudness becomes targetLUFS. Use
+// it on resampled output, with the output sample rate. Returns the linear gain
+// that was applied, or 1 if the buffer is silent or too short to measure.
+func NormalizeLUFS(data []float64, channels, sampleRate int, targetLUFS float64) float64 {
+	loudness := Loudness(data, channels, sampleRate)
+	if math.IsInf(loudness, -1) {
+		return 1
+	}
+
+	gain := math.Pow(10, (targetLUFS-loudness)/20)
+	for i := range data {
+		data[i] *= gain
+	}
+	return gain
+}
+
+func blockLoudness(meanSquare float64) float64 {
+	return -0.691 + 10*math.Log10(meanSquare)
+}
+
+// Creates the two filter stages of the BS.1770 K-weighting curve for any sample
+// rate: a high shelf modelling the head and a high-pass (RLB weighting).
+func kWeighting(sampleRate float64) (shelf, highPass biquad) {
+	k := math.Tan(math.Pi * 1681.974450955533 / sampleRate)
+	q := 0.7071752369554196
+	vh := math.Pow(10, 3.999843853973347/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1 + k/q + k*k
+	shelf = biquad{
+		b0: (vh + vb*k/q + k*k) / a0,
+		b1: 2 * (k*k - vh) / a0,
+		b2: (vh - vb*k/q + k*k) / a0,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	k = math.Tan(math.Pi * 38.13547087602444 / sampleRate)
+	q = 0.5003270373238773
+	a0 = 1 + k/q + k*k
+	highPass = biquad{
+		b0: 1,
+		b1: -2,
+		b2: 1,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+	return shelf, highPass
+}
+
+// Measures the true peak of an interleaved buffer, following ITU-R BS.1770: the
+// signal is oversampled 4 times (with two half-band stages, see
+// UpsampleHalfBandCascade) so peaks between the samples are caught, and the
+// largest absolute value is returned. It is linear; 20*log10 of it gives dBTP.
+// Returns 0 if channels is less than 1.
+func TruePeak(data []float64, channels int) float64 {
+	r, err := NewResampler(channels, 1, 4)
+	if err != nil {
+		return 0
+	}
+	peak := 0.0
+	for _, v := range r.UpsampleHalfBandCascade(data, 2) {
+		peak = math.Max(peak, math.Abs(v))
+	}
+	return peak
+}
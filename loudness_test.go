@@ -0,0 +1,81 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLoudness(t *testing.T) {
+	// BS.1770 calibration: a full scale 997 Hz sine in one channel is -3.01 LUFS.
+	in := make([]float64, 48000*3)
+	for i := range in {
+		in[i] = math.Sin(2 * math.Pi * 997 * float64(i) / 48000)
+	}
+	if got := Loudness(in, 1, 48000); math.Abs(got+3.01) > 0.05 {
+		t.Errorf("got %.3f LUFS, want -3.01", got)
+	}
+	for _, short := range [][]float64{nil, make([]float64, 48000), in[:1000]} {
+		if got := Loudness(short, 1, 48000); !math.IsInf(got, -1) {
+			t.Errorf("got %v LUFS for %d samples, want -Inf", got, len(short))
+		}
+	}
+}
+
+func TestNormalizeLUFS(t *testing.T) {
+	r := mustResampler(t, 1, 48000, 44100)
+	out := r.ResampleFloat64(sine(48000*3, 1, 997, 48000))
+	if gain := NormalizeLUFS(out, 1, 44100, -23); gain <= 0 || gain >= 1 {
+		t.Fatalf("got a gain of %v, want an attenuation", gain)
+	}
+	if got := Loudness(out, 1, 44100); math.Abs(got+23) > 1e-6 {
+		t.Fatalf("got %.3f LUFS after normalizing, want -23", got)
+	}
+	if gain := NormalizeLUFS(make([]float64, 44100), 1, 44100, -23); gain != 1 {
+		t.Errorf("got a gain of %v for silence, want 1", gain)
+	}
+}
+
+func TestTruePeak(t *testing.T) {
+	// A quarter of the sample rate, sampled halfway between its peaks.
+	in := make([]float64, 2000)
+	for i := range in {
+		in[i] = math.Sin(2*math.Pi*float64(i)/4 + math.Pi/4)
+	}
+	if got := TruePeak(in, 1); got < 0.99 || got > 1.06 {
+		t.Errorf("got a true peak of %v, want about 1 (sample peak is %v)", got, in[0])
+	}
+
+	// A slow tone peaks at its samples.
+	slow := sine(48000, 2, 100, 48000)
+	if got := TruePeak(slow, 2); math.Abs(got-0.8) > 0.01 {
+		t.Errorf("got a true peak of %v, want 0.8", got)
+	}
+	if got := TruePeak(slow, 0); got != 0 {
+		t.Errorf("got %v for 0 channels, want 0", got)
+	}
+}
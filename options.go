@@ -0,0 +1,217 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import (
+	"fmt"
+	"math"
+)
+
+// Configures optional Resampler behaviour. Options are passed to NewResampler.
+type Option func(*Resampler) error
+
+// Applies fn to every float sample right before it is quantized by the integer
+// methods (such as ResampleInt16). The result is still clamped afterwards, so fn
+// may safely overshoot the [-1, 1] range.
+func WithTransfer(fn func(float64) float64) Option {
+	return func(r *Resampler) error {
+		r.transfer = fn
+		return nil
+	}
+}
+
+// Makes the integer methods interpolate the raw integer values instead of
+// normalizing them to [-1, 1] first. Useful for non-audio signals stored as
+// integers. The result is rounded and clamped to the integer range. A transfer
+// set with WithTransfer receives the raw values in this mode.
+func WithRawIntScaling(raw bool) Option {
+	return func(r *Resampler) error {
+		r.rawInt = raw
+		return nil
+	}
+}
+
+// Makes ResampleFloat64 resample one channel at a time and write it straight
+// into the interleaved output, instead of splitting and resampling all channels
+// before interleaving them. This keeps the peak memory use close to the size of
+// the input plus the output, at the cost of reading the input once per channel.
+func WithLowMemory(lowMemory bool) Option {
+	return func(r *Resampler) error {
+		r.lowMemory = lowMemory
+		return nil
+	}
+}
+
+// How float samples are rounded when converted to integers.
+type RoundMode int
+
+const (
+	RoundNearest      RoundMode = iota // Round to nearest, halves away from zero.
+	RoundTowardZero                    // Truncate.
+	RoundTowardNegInf                  // Floor.
+)
+
+// Makes the integer methods divide samples by scale when converting them to
+// float and multiply them by scale (rounding with round) when converting them
+// back, instead of the default full scale of the format. For example, a scale
+// of 16384 reads and writes Q1.14 fixed point. The result is clamped to the
+// integer range.
+func WithIntegerScale(scale float64, round RoundMode) Option {
+	return func(r *Resampler) error {
+		if !(scale > 0) || math.IsInf(scale, 1) {
+			return fmt.Errorf("integer scale must be a positive number (got %v)", scale)
+		}
+		if round < RoundNearest || round > RoundTowardNegInf {
+			return fmt.Errorf("unknown rounding mode %d", round)
+		}
+		r.intScale = scale
+		r.rounding = round
+		return nil
+	}
+}
+
+// Restricts resampling to picking existing input samples, so no fractional
+// delay filtering (and no phase dispersion) is ever applied: downsampling keeps
+// every n-th sample and upsampling repeats every sample n times. Only possible
+// when one rate is an integer multiple of the other; NewResampler returns an
+// error otherwise. Unless a transfer or filter is configured, ResampleInt16 then
+// copies the samples without converting them to float, so the result is
+// bit-exact.
+func WithIntegerDelays(enabled bool) Option {
+	return func(r *Resampler) error {
+		if enabled && r.FromRate%r.ToRate != 0 && r.ToRate%r.FromRate != 0 {
+			return fmt.Errorf("%d Hz to %d Hz is not an integer ratio, so it cannot be done with integer delays", r.FromRate, r.ToRate)
+		}
+		r.integerDelays = enabled
+		return nil
+	}
+}
+
+// Applies a noise gate to every input channel before interpolation: while the
+// level of a channel stays below thresholdDB (in dBFS) it fades out over
+// releaseMs, and once it rises above the threshold it fades back in over
+// attackMs. Not applied when FromRate equals ToRate, since the input is then
+// returned as is.
+func WithNoiseGate(thresholdDB, attackMs, releaseMs float64) Option {
+	return func(r *Resampler) error {
+		if math.IsNaN(thresholdDB) {
+			return fmt.Errorf("noise gate threshold must be a number")
+		}
+		if !(attackMs >= 0) || !(releaseMs >= 0) {
+			return fmt.Errorf("noise gate attack and release must not be negative (got %v and %v)", attackMs, releaseMs)
+		}
+		r.gate = newNoiseGate(float64(r.FromRate), thresholdDB, attackMs, releaseMs)
+		return nil
+	}
+}
+
+// Makes the first and last output frame of every channel exactly equal to the
+// first and last input frame, instead of values interpolated near them, so
+// resampled segments can be cross-faded cleanly. Frames in between are
+// interpolated and filtered as usual; the endpoints are set after the output
+// filters such as WithOutputDCBlock, and only scaled by the gain. Applies to the
+// one-shot methods, not to streams.
+func WithAnchorEndpoints(anchor bool) Option {
+	return func(r *Resampler) error {
+		r.anchorEndpoints = anchor
+		return nil
+	}
+}
+
+// Limits the output of a single resample call to n samples, as a safety limit
+// against tiny inputs with extreme ratios forcing huge allocations. The limit is
+// checked before anything is allocated: the error-returning variants then return
+// ErrOutputTooLarge and the others return nil. Streams apply it to every chunk:
+// Process consumes only as much input as fits and Write returns
+// ErrOutputTooLarge, while the tail returned by Flush, the output of the last
+// few input frames, is not limited. A limit of 0 disables it.
+func WithMaxOutputSamples(n int) Option {
+	return func(r *Resampler) error {
+		if n < 0 {
+			return fmt.Errorf("maximum output samples must not be negative (got %d)", n)
+		}
+		r.maxOutput = n
+		return nil
+	}
+}
+
+// Removes DC from every resampled output channel with a one-pole DC blocker
+// (cutoff around 10 Hz), for downstream AC-coupled gear. Like the input filters,
+// it is not applied when FromRate equals ToRate, since the input is then
+// returned as is.
+func WithOutputDCBlock(enabled bool) Option {
+	return func(r *Resampler) error {
+		r.dcBlock = enabled
+		return nil
+	}
+}
+
+// Interpolates linearly between the two nearest input samples wherever the
+// interpolation window holds a sample of magnitude level or more, so the
+// overshoot of the spline (or sinc) cannot push the output past full scale
+// near loud peaks. Elsewhere the configured mode is used. For example, 0.9
+// guards everything within about 1 dB of full scale. A level of 0 disables it.
+func WithOvershootGuard(level float64) Option {
+	return func(r *Resampler) error {
+		if !(level >= 0) || math.IsInf(level, 1) {
+			return fmt.Errorf("overshoot guard level must be a non-negative number (got %v)", level)
+		}
+		r.overshootGuard = level
+		return nil
+	}
+}
+
+// Scales every output sample by gain, so output can be attenuated or normalized
+// without another pass over it. The gain is folded into the interpolation, and
+// the integer methods apply it before clamping. Unlike the filters, it also
+// applies when FromRate equals ToRate, in which case the input is copied and
+// scaled. Without this option the gain is 1.
+func WithGain(gain float64) Option {
+	return func(r *Resampler) error {
+		if gain == 0 || math.IsNaN(gain) || math.IsInf(gain, 0) {
+			return fmt.Errorf("gain must be a finite non-zero number (got %v)", gain)
+		}
+		if gain != 1 {
+			r.gain = gain
+		}
+		return nil
+	}
+}
+
+// Runs the input filters (WithNoiseGate, WithEQ and AntiAlias) over each channel
+// forwards and then backwards, so their phase shifts cancel and zero crossings
+// keep their timing, as pitch trackers need. The interpolation itself adds no
+// delay; the linear and sinc kernels are symmetric, while the spline is slightly
+// skewed towards the samples after the read position. Since every filter is
+// applied twice, magnitude responses are squared: EQ gains and the anti-aliasing
+// attenuation double in dB. Streams cannot look ahead, so they always filter
+// forwards only.
+func WithZeroPhaseFilters(enabled bool) Option {
+	return func(r *Resampler) error {
+		r.zeroPhase = enabled
+		return nil
+	}
+}
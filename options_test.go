@@ -0,0 +1,441 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"testing"
+)
+
+func sineInt16(n int, amplitude float64) []int16 {
+	data := make([]int16, n)
+	for i := range data {
+		data[i] = int16(amplitude * math.Sin(float64(i)/5))
+	}
+	return data
+}
+
+func TestWithTransfer(t *testing.T) {
+	in := sineInt16(200, 30000)
+	want := mustResampler(t, 1, 8000, 16000).ResampleInt16(in)
+
+	identity := mustResampler(t, 1, 8000, 16000, WithTransfer(func(v float64) float64 { return v }))
+	got := identity.ResampleInt16(in)
+	if len(got) != len(want) {
+		t.Fatalf("got %d samples, want %d", len(got), len(want))
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("identity transfer changed sample %d: got %d, want %d", i, got[i], want[i])
+		}
+	}
+
+	overdrive := mustResampler(t, 1, 8000, 16000, WithTransfer(func(v float64) float64 { return 4 * v }))
+	clipped := 0
+	for i, v := range overdrive.ResampleInt16(in) {
+		if (v < 0) != (want[i] < 0) && want[i] != 0 {
+			t.Fatalf("sample %d wrapped around: got %d, unshaped %d", i, v, want[i])
+		}
+		if v == math.MaxInt16 || v == math.MinInt16 {
+			clipped++
+		}
+	}
+	if clipped == 0 {
+		t.Fatal("expected the overshooting transfer to be clamped")
+	}
+}
+
+func TestWithRawIntScaling(t *testing.T) {
+	in := make([]int16, 100)
+	for i := range in {
+		in[i] = int16(i * 300)
+	}
+	r := mustResampler(t, 1, 1000, 3000, WithRawIntScaling(true))
+	out := r.ResampleInt16(in)
+	// A spline through a ramp is the ramp itself, so away from the end, where
+	// the window repeats the last sample, every output sample lands on it.
+	for i, v := range out[:len(out)-12] {
+		if want := i * 100; int(v) != want {
+			t.Fatalf("sample %d: got %d, want %d", i, v, want)
+		}
+	}
+
+	var peak float64
+	shaped := mustResampler(t, 1, 1000, 3000, WithRawIntScaling(true), WithTransfer(func(v float64) float64 {
+		peak = math.Max(peak, v)
+		return v
+	}))
+	shaped.ResampleInt16(in)
+	if peak < 29000 {
+		t.Fatalf("transfer saw a peak of %v, want the raw integer values", peak)
+	}
+}
+
+func TestWithLowMemory(t *testing.T) {
+	for _, rates := range [][2]int{{44100, 48000}, {48000, 8000}} {
+		for _, n := range []int{3, 17 * 6, 1001, 5000, 5003} {
+			in := sine(n, 1, 300, 44100)
+			want := mustResampler(t, 6, rates[0], rates[1]).ResampleFloat64(in)
+			low := mustResampler(t, 6, rates[0], rates[1], WithLowMemory(true))
+			assertEqualSamples(t, low.ResampleFloat64(in), want)
+		}
+	}
+}
+
+// Compares the allocations of splitting every channel out first against
+// resampling one channel at a time into the output, on 16 channels, serially
+// like WithLowMemory resamples.
+func BenchmarkWithLowMemory(b *testing.B) {
+	in := sine(1<<16, 16, 440, 44100)
+	for _, bm := range []struct {
+		name string
+		low  bool
+	}{{"Split", false}, {"LowMemory", true}} {
+		r := mustResampler(b, 16, 44100, 48000, WithLowMemory(bm.low))
+		r.MaxConcurrency = 1
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(8 * len(in)))
+			for i := 0; i < b.N; i++ {
+				r.ResampleFloat64(in)
+			}
+		})
+	}
+}
+
+func TestWithIntegerScale(t *testing.T) {
+	in := []int16{-16384, -8192, 0, 1, 8192, 16383, 32767}
+	r := mustResampler(t, 1, 8000, 8000, WithIntegerScale(16384, RoundNearest))
+	for i, v := range r.ResampleInt16(in) {
+		if v != in[i] {
+			t.Fatalf("sample %d: got %d, want %d", i, v, in[i])
+		}
+	}
+
+	// Linear interpolation puts the odd output samples halfway between the
+	// input samples, at ±0.5 of a step.
+	zigzag := []int16{0, 1, 0, -1, 0, 1, 0, -1}
+	for _, tt := range []struct {
+		round RoundMode
+		odd   []int16
+	}{
+		{RoundNearest, []int16{1, 1, -1, -1}},
+		{RoundTowardZero, []int16{0, 0, 0, 0}},
+		{RoundTowardNegInf, []int16{0, 0, -1, -1}},
+	} {
+		r := mustResampler(t, 1, 8000, 16000, WithIntegerScale(16384, tt.round), WithInterpolation(InterpLinear))
+		out := r.ResampleInt16(zigzag)
+		for i, want := range tt.odd {
+			if got := out[2*i+1]; got != want {
+				t.Errorf("rounding mode %d: sample %d is %d, want %d", tt.round, 2*i+1, got, want)
+			}
+		}
+	}
+
+	for _, scale := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		if _, err := NewResampler(1, 8000, 8000, WithIntegerScale(scale, RoundNearest)); err == nil {
+			t.Errorf("expected an error for a scale of %v", scale)
+		}
+	}
+	if _, err := NewResampler(1, 8000, 8000, WithIntegerScale(1, RoundMode(9))); err == nil {
+		t.Error("expected an error for an unknown rounding mode")
+	}
+}
+
+func TestWithIntegerDelays(t *testing.T) {
+	if _, err := NewResampler(1, 44100, 48000, WithIntegerDelays(true)); err == nil {
+		t.Error("expected an error for a non-integer ratio")
+	}
+	if _, err := NewResampler(1, 44100, 48000, WithIntegerDelays(false)); err != nil {
+		t.Errorf("disabling integer delays should always work: %v", err)
+	}
+
+	in := make([]float64, 100)
+	for i := range in {
+		in[i] = float64(i)
+	}
+	down := mustResampler(t, 1, 48000, 24000, WithIntegerDelays(true)).ResampleFloat64(in)
+	for i, v := range down {
+		if v != float64(2*i) {
+			t.Fatalf("downsampling: sample %d is %v, want %d", i, v, 2*i)
+		}
+	}
+	up := mustResampler(t, 1, 24000, 72000, WithIntegerDelays(true)).ResampleFloat64(in)
+	for i, v := range up {
+		if v != float64(i/3) {
+			t.Fatalf("upsampling: sample %d is %v, want %d", i, v, i/3)
+		}
+	}
+}
+
+func TestWithAnchorEndpoints(t *testing.T) {
+	in := sine(1001, 2, 300, 44100)
+	for _, to := range []int{48000, 8000} {
+		plain := mustResampler(t, 2, 44100, to).ResampleFloat64(in)
+		for _, low := range []bool{false, true} {
+			out := mustResampler(t, 2, 44100, to, WithAnchorEndpoints(true), WithLowMemory(low)).ResampleFloat64(in)
+			if len(out) != len(plain) {
+				t.Fatalf("got %d samples, want %d", len(out), len(plain))
+			}
+			for c := 0; c < 2; c++ {
+				if out[c] != in[c] || out[len(out)-2+c] != in[len(in)-2+c] {
+					t.Errorf("%d Hz, low memory %v: channel %d does not start and end on the input", to, low, c)
+				}
+			}
+			assertEqualSamples(t, out[2:len(out)-2], plain[2:len(plain)-2])
+		}
+	}
+
+	// The output filters run before the endpoints are set, so they keep them
+	// exact and filter the frames in between as without anchoring.
+	offset := make([]float64, len(in))
+	for i, v := range in {
+		offset[i] = v + 0.25
+	}
+	for _, low := range []bool{false, true} {
+		plain := mustResampler(t, 2, 44100, 48000, WithOutputDCBlock(true), WithLowMemory(low)).ResampleFloat64(offset)
+		out := mustResampler(t, 2, 44100, 48000, WithOutputDCBlock(true), WithAnchorEndpoints(true), WithLowMemory(low)).ResampleFloat64(offset)
+		for c := 0; c < 2; c++ {
+			if out[c] != offset[c] || out[len(out)-2+c] != offset[len(offset)-2+c] {
+				t.Errorf("DC block, low memory %v: channel %d does not start and end on the input", low, c)
+			}
+		}
+		assertEqualSamples(t, out[2:len(out)-2], plain[2:len(plain)-2])
+	}
+}
+
+func TestWithMaxOutputSamples(t *testing.T) {
+	in := sine(1000, 1, 440, 8000)
+	r := mustResampler(t, 1, 8000, 48000, WithMaxOutputSamples(5999))
+
+	if _, err := r.ResampleFloat64E(in); !errors.Is(err, ErrOutputTooLarge) {
+		t.Errorf("ResampleFloat64E: got %v, want ErrOutputTooLarge", err)
+	}
+	if _, err := r.ResampleFloat64Into(make([]float64, 6000), in); !errors.Is(err, ErrOutputTooLarge) {
+		t.Errorf("ResampleFloat64Into: got %v, want ErrOutputTooLarge", err)
+	}
+	if _, err := r.ResampleBytes(make([]byte, 2000), binary.LittleEndian); !errors.Is(err, ErrOutputTooLarge) {
+		t.Errorf("ResampleBytes: got %v, want ErrOutputTooLarge", err)
+	}
+	if out := r.ResampleFloat64(in); out != nil {
+		t.Errorf("ResampleFloat64 returned %d samples, want nil", len(out))
+	}
+	if out1, out2 := r.ResampleDual(in, 48000, 16000); out1 != nil || out2 == nil {
+		t.Error("ResampleDual should only drop the output that is too large")
+	}
+	if out := r.UpsampleHalfBandCascade(in, 3); out != nil {
+		t.Errorf("UpsampleHalfBandCascade returned %d samples, want nil", len(out))
+	}
+	if mainOut, sideOut := r.ResampleSidechain(in, in); mainOut != nil || sideOut != nil {
+		t.Error("ResampleSidechain should apply the limit to the sidechain too")
+	}
+	if out := r.ResampleViaIntermediate(in, 24000); out != nil {
+		t.Errorf("ResampleViaIntermediate returned %d samples, want nil", len(out))
+	}
+	emitted := false
+	r.ResampleFloat64Blocks(in, 64, func([]float64) { emitted = true })
+	if emitted {
+		t.Error("ResampleFloat64Blocks emitted output past the limit")
+	}
+
+	// The limit itself is allowed.
+	exact := mustResampler(t, 1, 8000, 48000, WithMaxOutputSamples(6000))
+	if out, err := exact.ResampleFloat64E(in); err != nil || len(out) != 6000 {
+		t.Errorf("got %d samples and %v at the limit", len(out), err)
+	}
+	if _, err := NewResampler(1, 8000, 48000, WithMaxOutputSamples(-1)); err == nil {
+		t.Error("expected an error for a negative limit")
+	}
+}
+
+// Streams keep the output of every call within the limit: Process consumes only
+// the frames whose output fits and leaves the rest for the next call, and Write
+// refuses chunks that do not fit.
+func TestMaxOutputSamplesInStreams(t *testing.T) {
+	in := sine(1000, 2, 440, 8000)
+	want := mustResampler(t, 2, 8000, 48000).ResampleFloat64(in)
+	r := mustResampler(t, 2, 8000, 48000, WithMaxOutputSamples(1200))
+
+	stream := r.Stream()
+	var got []float64
+	for rest := in; len(rest) > 0; {
+		out, consumed, _ := stream.Process(rest)
+		if len(out) > 1200 {
+			t.Fatalf("Process returned %d samples, over the limit", len(out))
+		}
+		if consumed == 0 {
+			t.Fatal("Process consumed nothing")
+		}
+		got = append(got, out...)
+		rest = rest[consumed:]
+	}
+	got = append(got, stream.Flush()...)
+	assertEqualSamples(t, got, want)
+
+	if _, err := r.Stream().Write(in); !errors.Is(err, ErrOutputTooLarge) {
+		t.Errorf("Write: got %v, want ErrOutputTooLarge", err)
+	}
+	stream = r.Stream()
+	if out, err := stream.Write(in[:200]); err != nil || len(out) == 0 || len(out) > 1200 {
+		t.Errorf("Write of a chunk within the limit: got %d samples and %v", len(out), err)
+	}
+
+	pcm := make([]byte, 2*len(in))
+	if _, err := io.ReadAll(NewReader(bytes.NewReader(pcm), r, binary.LittleEndian)); !errors.Is(err, ErrOutputTooLarge) {
+		t.Errorf("NewReader: got %v, want ErrOutputTooLarge", err)
+	}
+}
+
+// With integer delays ResampleInt16 copies samples without converting them to
+// float, and gives the same result as the float path.
+func TestIntegerDelaysAreBitExact(t *testing.T) {
+	for _, rates := range [][2]int{{8000, 16000}, {48000, 16000}, {44100, 44100 * 3}, {48000, 8000}} {
+		for _, channels := range []int{1, 2, 3} {
+			for _, n := range []int{1, 2, 5, 17, 1001} {
+				in := make([]int16, n)
+				for i := range in {
+					in[i] = int16(i*7919%65536 - 32768)
+				}
+				r := mustResampler(t, channels, rates[0], rates[1], WithIntegerDelays(true), WithAnchorEndpoints(n%2 == 0))
+				if !r.integerOnly() {
+					t.Fatal("expected the integer path")
+				}
+				got := r.ResampleInt16(in)
+				// An identity transfer forces the float path.
+				r.transfer = func(v float64) float64 { return v }
+				want := r.ResampleInt16(in)
+				if len(got) != len(want) {
+					t.Fatalf("%v, %d channels, %d samples: got %d samples, want %d", rates, channels, n, len(got), len(want))
+				}
+				for i := range got {
+					if got[i] != want[i] {
+						t.Fatalf("%v, %d channels, %d samples: sample %d is %d, want %d", rates, channels, n, i, got[i], want[i])
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestWithOvershootGuard(t *testing.T) {
+	in := make([]float64, 4000)
+	for i := range in {
+		in[i] = 0.99
+		if i/7%2 != 0 {
+			in[i] = -0.99
+		}
+	}
+	countClipped := func(r *Resampler) int {
+		n := 0
+		for _, v := range r.ResampleFloat64(in) {
+			if math.Abs(v) > 1 {
+				n++
+			}
+		}
+		return n
+	}
+	if n := countClipped(mustResampler(t, 1, 44100, 48000)); n == 0 {
+		t.Fatal("expected the spline to overshoot without the guard")
+	}
+	if n := countClipped(mustResampler(t, 1, 44100, 48000, WithOvershootGuard(0.9))); n != 0 {
+		t.Errorf("%d samples overshoot with the guard, want 0", n)
+	}
+	for _, level := range []float64{-1, math.NaN(), math.Inf(1)} {
+		if _, err := NewResampler(1, 44100, 48000, WithOvershootGuard(level)); err == nil {
+			t.Errorf("expected an error for a level of %v", level)
+		}
+	}
+}
+
+func TestWithGain(t *testing.T) {
+	for _, gain := range []float64{0, math.NaN(), math.Inf(1), math.Inf(-1)} {
+		if _, err := NewResampler(1, 44100, 48000, WithGain(gain)); err == nil {
+			t.Errorf("expected an error for a gain of %v", gain)
+		}
+	}
+
+	in := sine(1001, 2, 440, 44100)
+	in32 := make([]float32, len(in))
+	for i, v := range in {
+		in32[i] = float32(v)
+	}
+	in16 := sineInt16(1000, 20000)
+	for _, rates := range [][2]int{{44100, 48000}, {44100, 44100}} {
+		plain := mustResampler(t, 2, rates[0], rates[1])
+		half := mustResampler(t, 2, rates[0], rates[1], WithGain(0.5))
+		// At equal rates, the plain output is in itself.
+		want := append([]float64(nil), plain.ResampleFloat64(in)...)
+		for i := range want {
+			want[i] *= 0.5
+		}
+		check := func(method string, got []float64) {
+			t.Helper()
+			if len(got) != len(want) {
+				t.Fatalf("%d Hz to %d Hz, %s: got %d samples, want %d", rates[0], rates[1], method, len(got), len(want))
+			}
+			for i := range got {
+				if math.Abs(got[i]-want[i]) > 1e-6 {
+					t.Fatalf("%d Hz to %d Hz, %s: sample %d is %v, want %v", rates[0], rates[1], method, i, got[i], want[i])
+				}
+			}
+		}
+		widen := func(data []float32) []float64 {
+			out := make([]float64, len(data))
+			for i, v := range data {
+				out[i] = float64(v)
+			}
+			return out
+		}
+
+		check("ResampleFloat64", half.ResampleFloat64(in))
+		into := make([]float64, len(want))
+		if _, err := half.ResampleFloat64Into(into, in); err != nil {
+			t.Fatal(err)
+		}
+		check("ResampleFloat64Into", into)
+		check("ResampleRing", half.ResampleRing(in[:501], in[501:]))
+		check("ResampleToFloat32", widen(half.ResampleToFloat32(in)))
+		check("ResampleFloat32", widen(half.ResampleFloat32(in32)))
+		s := half.Stream()
+		out, _, _ := s.Process(in)
+		check("StreamResampler", append(out, s.Flush()...))
+
+		// The integer methods apply the gain before rounding.
+		want16 := plain.ResampleInt16(in16)
+		for i, v := range half.ResampleInt16(in16) {
+			if d := float64(v) - float64(want16[i])/2; math.Abs(d) > 1 {
+				t.Fatalf("%d Hz to %d Hz, ResampleInt16: sample %d is %d, want about %v", rates[0], rates[1], i, v, float64(want16[i])/2)
+			}
+		}
+	}
+
+	unity := mustResampler(t, 2, 44100, 48000, WithGain(1))
+	assertEqualSamples(t, unity.ResampleFloat64(in), mustResampler(t, 2, 44100, 48000).ResampleFloat64(in))
+}
@@ -0,0 +1,240 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Resamples an unsigned offset-binary buffer, where midpoint is the value of
+// silence. The midpoint is subtracted, the raw values are resampled without
+// normalizing them and the midpoint is added back, clamping to the uint16
+// range. Returns the resampled buffer.
+func (r *Resampler) ResampleOffsetBinary(data []uint16, midpoint uint16) []uint16 {
+	if len(data) == 0 {
+		return nil
+	}
+
+	f64 := make([]float64, len(data))
+	for i, v := range data {
+		f64[i] = float64(v) - float64(midpoint)
+	}
+
+	outF64 := r.ResampleFloat64(f64)
+
+	out := make([]uint16, len(outF64))
+	for i, v := range outF64 {
+		out[i] = uint16(r.roundClamp(v+float64(midpoint), 0, math.MaxUint16))
+	}
+	return out
+}
+
+// Reads interleaved 16-bit PCM from src until EOF and resamples it like
+// ResampleInt16. Returns an error if reading fails or the data ends with an odd
+// byte, and ErrOutputTooLarge if the output would exceed the limit set with
+// WithMaxOutputSamples.
+func (r *Resampler) ResampleInt16Reader(src io.Reader, byteOrder binary.ByteOrder) ([]int16, error) {
+	raw, err := io.ReadAll(src)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw)%2 != 0 {
+		return nil, fmt.Errorf("16-bit PCM must have an even amount of bytes (got %d)", len(raw))
+	}
+	if r.outputTooLarge(len(raw) / 2) {
+		return nil, ErrOutputTooLarge
+	}
+
+	return r.ResampleInt16(decodeInt16(raw, byteOrder)), nil
+}
+
+// Resamples interleaved 16-bit PCM bytes like ResampleInt16 and returns the
+// result in the same byte order. Returns an error if data does not hold a whole
+// amount of frames, and ErrOutputTooLarge if the output would exceed the limit
+// set with WithMaxOutputSamples.
+func (r *Resampler) ResampleBytes(data []byte, order binary.ByteOrder) ([]byte, error) {
+	if frameBytes := 2 * r.Channels; len(data)%frameBytes != 0 {
+		return nil, fmt.Errorf("16-bit PCM with %d channels must be a multiple of %d bytes (got %d)", r.Channels, frameBytes, len(data))
+	}
+	if r.outputTooLarge(len(data) / 2) {
+		return nil, ErrOutputTooLarge
+	}
+
+	resampled := r.ResampleInt16(decodeInt16(data, order))
+	out := make([]byte, 2*len(resampled))
+	for i, v := range resampled {
+		order.PutUint16(out[i*2:], uint16(v))
+	}
+	return out, nil
+}
+
+// Decodes 16-bit PCM bytes. A trailing odd byte is ignored.
+func decodeInt16(raw []byte, order binary.ByteOrder) []int16 {
+	data := make([]int16, len(raw)/2)
+	for i := range data {
+		data[i] = int16(order.Uint16(raw[i*2:]))
+	}
+	return data
+}
+
+// Reads interleaved 16-bit PCM from an underlying reader and yields it
+// resampled, in the same byte order. Created with NewReader.
+type pcmReader struct {
+	src    io.Reader
+	stream *StreamResampler
+	order  binary.ByteOrder
+	in     []byte // Read buffer; holds a partial sample between reads.
+	queued int    // Bytes of a partial sample at the start of in.
+	out    []byte // Resampled bytes not returned yet.
+	err    error  // Returned once out is drained.
+}
+
+// Returns a reader that reads interleaved 16-bit PCM in the given byte order
+// from src and yields it resampled by resampler, like ResampleInt16 would for
+// the whole input at once. The input is resampled as it is read, through a
+// stream, so it is never held in memory as a whole. A partial frame at the end
+// of the input is dropped, and an odd trailing byte is reported as an error, as
+// is ErrOutputTooLarge if the output of a read would exceed the limit set with
+// WithMaxOutputSamples.
+func NewReader(src io.Reader, resampler *Resampler, order binary.ByteOrder) io.Reader {
+	return &pcmReader{
+		src:    src,
+		stream: resampler.Stream(),
+		order:  order,
+		in:     make([]byte, 4096*2*resampler.Channels),
+	}
+}
+
+func (p *pcmReader) Read(buf []byte) (int, error) {
+	for len(p.out) == 0 && p.err == nil {
+		p.fill()
+	}
+	if len(p.out) > 0 {
+		n := copy(buf, p.out)
+		p.out = p.out[n:]
+		return n, nil
+	}
+	return 0, p.err
+}
+
+// Reads the next chunk of the input and queues its resampled bytes, or the
+// flushed end of the stream once the input ends.
+func (p *pcmReader) fill() {
+	r := p.stream.resampler
+	n, err := p.src.Read(p.in[p.queued:])
+	n += p.queued
+	whole := n - n%2
+
+	samples := make([]float64, whole/2)
+	for i := range samples {
+		samples[i] = r.fromInt16(int16(p.order.Uint16(p.in[i*2:])))
+	}
+	p.queued = copy(p.in, p.in[whole:n])
+	resampled, writeErr := p.stream.Write(samples)
+	if writeErr != nil {
+		p.err = writeErr
+		return
+	}
+
+	if errors.Is(err, io.EOF) {
+		if p.queued != 0 {
+			p.err = fmt.Errorf("16-bit PCM must have an even amount of bytes")
+			return
+		}
+		resampled = append(resampled, p.stream.Flush()...)
+		p.err = io.EOF
+	} else if err != nil {
+		p.err = err
+	}
+
+	p.out = make([]byte, 2*len(resampled))
+	for i, v := range resampled {
+		p.order.PutUint16(p.out[i*2:], uint16(r.toInt16(v)))
+	}
+}
+
+// The range of 24-bit samples.
+const (
+	minInt24 = -1 << 23
+	maxInt24 = 1<<23 - 1
+)
+
+// Resamples interleaved 24-bit PCM, packed into 3 bytes per sample in the given
+// byte order, and returns the result packed the same way. Samples are
+// normalized like ResampleInt16 does, with -8388608 mapping to -1, and clamped
+// to the 24-bit range on the way back. Returns an error if data does not hold a
+// whole amount of frames, and ErrOutputTooLarge if the output would exceed the
+// limit set with WithMaxOutputSamples.
+func (r *Resampler) ResampleInt24(data []byte, order binary.ByteOrder) ([]byte, error) {
+	if frameBytes := 3 * r.Channels; len(data)%frameBytes != 0 {
+		return nil, fmt.Errorf("24-bit PCM with %d channels must be a multiple of %d bytes (got %d)", r.Channels, frameBytes, len(data))
+	}
+	if r.outputTooLarge(len(data) / 3) {
+		return nil, ErrOutputTooLarge
+	}
+
+	bigEndian := isBigEndian(order)
+	scale := r.integerScale(1 << 23)
+	f64 := make([]float64, len(data)/3)
+	for i := range f64 {
+		b := data[i*3 : i*3+3]
+		var u uint32
+		if bigEndian {
+			u = uint32(b[0])<<16 | uint32(b[1])<<8 | uint32(b[2])
+		} else {
+			u = uint32(b[2])<<16 | uint32(b[1])<<8 | uint32(b[0])
+		}
+		// Sign-extend from bit 23
+		f64[i] = float64(int32(u<<8)>>8) / scale
+	}
+
+	resampled := r.ResampleFloat64(f64)
+	out := make([]byte, 3*len(resampled))
+	for i, v := range resampled {
+		if r.transfer != nil {
+			v = r.transfer(v)
+		}
+		u := uint32(int32(r.roundClamp(v*scale, minInt24, maxInt24)))
+		b := out[i*3 : i*3+3]
+		if bigEndian {
+			b[0], b[1], b[2] = byte(u>>16), byte(u>>8), byte(u)
+		} else {
+			b[0], b[1], b[2] = byte(u), byte(u>>8), byte(u>>16)
+		}
+	}
+	return out, nil
+}
+
+// Reports whether order stores the most significant byte first.
+func isBigEndian(order binary.ByteOrder) bool {
+	var probe [2]byte
+	order.PutUint16(probe[:], 1)
+	return probe[0] == 0
+}
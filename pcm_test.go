@@ -0,0 +1,184 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"testing"
+	"testing/iotest"
+)
+
+func TestResampleOffsetBinary(t *testing.T) {
+	r := mustResampler(t, 1, 8000, 11025)
+	in := make([]uint16, 200)
+	for i := range in {
+		in[i] = 2048
+	}
+	for i, v := range r.ResampleOffsetBinary(in, 2048) {
+		if v != 2048 {
+			t.Fatalf("silence became %d at sample %d", v, i)
+		}
+	}
+
+	// Matches resampling the signed values around the midpoint, clamped to
+	// the unsigned range instead of wrapping around.
+	sineWave := make([]uint16, 200)
+	square := make([]uint16, 200)
+	for i := range sineWave {
+		sineWave[i] = uint16(2048 + 1000*math.Sin(float64(i)/4))
+		if i/3%2 == 0 {
+			square[i] = math.MaxUint16
+		}
+	}
+	for _, tt := range []struct {
+		in       []uint16
+		midpoint uint16
+	}{{sineWave, 2048}, {square, 32768}} {
+		signed := make([]float64, len(tt.in))
+		for i, v := range tt.in {
+			signed[i] = float64(v) - float64(tt.midpoint)
+		}
+		want := r.ResampleFloat64(signed)
+		for i, v := range r.ResampleOffsetBinary(tt.in, tt.midpoint) {
+			w := math.Min(math.Max(math.Round(want[i]+float64(tt.midpoint)), 0), math.MaxUint16)
+			if float64(v) != w {
+				t.Fatalf("midpoint %d, sample %d: got %d, want %v", tt.midpoint, i, v, w)
+			}
+		}
+	}
+}
+
+func encodeInt16(data []int16, order binary.ByteOrder) []byte {
+	raw := make([]byte, 2*len(data))
+	for i, v := range data {
+		order.PutUint16(raw[i*2:], uint16(v))
+	}
+	return raw
+}
+
+func TestResampleInt16Reader(t *testing.T) {
+	in := sineInt16(300, 1000)
+	r := mustResampler(t, 1, 8000, 11025)
+	want := r.ResampleInt16(in)
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		got, err := r.ResampleInt16Reader(bytes.NewReader(encodeInt16(in, order)), order)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("got %d samples, want %d", len(got), len(want))
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Fatalf("sample %d: got %d, want %d", i, got[i], want[i])
+			}
+		}
+	}
+	if _, err := r.ResampleInt16Reader(bytes.NewReader(make([]byte, 3)), binary.LittleEndian); err == nil {
+		t.Error("expected an error for an odd amount of bytes")
+	}
+}
+
+func TestResampleBytes(t *testing.T) {
+	in := sineInt16(600, 20000)
+	r := mustResampler(t, 2, 44100, 48000)
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		out, err := r.ResampleBytes(encodeInt16(in, order), order)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := encodeInt16(r.ResampleInt16(in), order); !bytes.Equal(out, want) {
+			t.Errorf("%v: got %d bytes that differ from the %d expected", order, len(out), len(want))
+		}
+	}
+	if _, err := r.ResampleBytes(make([]byte, 6), binary.LittleEndian); err == nil {
+		t.Error("expected an error for a partial frame")
+	}
+}
+
+func TestNewReader(t *testing.T) {
+	in := sineInt16(5000, 20000)
+	r := mustResampler(t, 2, 44100, 48000)
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		want := encodeInt16(r.ResampleInt16(in), order)
+		for _, src := range []io.Reader{
+			bytes.NewReader(encodeInt16(in, order)),
+			// Splits samples across reads.
+			iotest.OneByteReader(bytes.NewReader(encodeInt16(in, order))),
+		} {
+			got, err := io.ReadAll(NewReader(src, r, order))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%v: got %d bytes that differ from the %d of ResampleInt16", order, len(got), len(want))
+			}
+		}
+	}
+	if _, err := io.ReadAll(NewReader(bytes.NewReader(make([]byte, 5)), mustResampler(t, 1, 8000, 16000), binary.LittleEndian)); err == nil {
+		t.Error("expected an error for an odd trailing byte")
+	}
+}
+
+func TestResampleInt24(t *testing.T) {
+	in := make([]byte, 3*500)
+	for i := range in {
+		in[i] = byte(i * 37)
+	}
+	r := mustResampler(t, 1, 8000, 16000, WithIntegerDelays(true))
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		out, err := r.ResampleInt24(in, order)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(out) != 2*len(in) {
+			t.Fatalf("got %d bytes, want %d", len(out), 2*len(in))
+		}
+		// Every input sample is held for two output samples.
+		for i := 0; i < len(in); i += 3 {
+			if want := in[i : i+3]; !bytes.Equal(out[2*i:2*i+3], want) || !bytes.Equal(out[2*i+3:2*i+6], want) {
+				t.Fatalf("%v: sample %d came out as % x, want % x twice", order, i/3, out[2*i:2*i+6], want)
+			}
+		}
+	}
+
+	// The transfer's overshoot is clamped to the 24-bit range.
+	loud := mustResampler(t, 1, 8000, 16000, WithTransfer(func(v float64) float64 { return 4 * v }))
+	out, err := loud.ResampleInt24([]byte{0xff, 0xff, 0x7f, 0x00, 0x00, 0x80}, binary.LittleEndian)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out[:3], []byte{0xff, 0xff, 0x7f}) || !bytes.Equal(out[len(out)-3:], []byte{0x00, 0x00, 0x80}) {
+		t.Errorf("got % x, want the ends clamped to 7fffff and 800000", out)
+	}
+
+	if _, err := mustResampler(t, 2, 8000, 16000).ResampleInt24(make([]byte, 9), binary.LittleEndian); err == nil {
+		t.Error("expected an error for a partial frame")
+	}
+}
@@ -0,0 +1,68 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+// Resamples a float64 audio buffer and computes a coarse peak envelope of the
+// output for drawing waveforms, with peaksPerSecond windows per second of
+// output. peaks holds the minimum and maximum sample of every window across all
+// channels, as consecutive min, max pairs. A window holds at least one output
+// frame, so peaksPerSecond is capped at ToRate. Returns the resampled buffer and
+// the peaks, or nil peaks if peaksPerSecond is below 1.
+func (r *Resampler) ResampleWithPeaks(data []float64, peaksPerSecond int) (out []float64, peaks []float64) {
+	out = r.ResampleFloat64(data)
+	if peaksPerSecond < 1 || len(out) == 0 {
+		return out, nil
+	}
+
+	if peaksPerSecond > r.ToRate {
+		peaksPerSecond = r.ToRate
+	}
+
+	// In 64 bits, since frames times peaksPerSecond overflows a 32-bit int
+	frames := len(out) / r.Channels
+	perSecond, rate := int64(peaksPerSecond), int64(r.ToRate)
+	windows := int((int64(frames)*perSecond + rate - 1) / rate)
+	peaks = make([]float64, windows*2)
+	current := -1
+	for f := 0; f < frames; f++ {
+		w := int(int64(f) * perSecond / rate)
+		for c := 0; c < r.Channels; c++ {
+			v := out[f*r.Channels+c]
+			if w != current {
+				peaks[w*2], peaks[w*2+1] = v, v
+				current = w
+				continue
+			}
+			if v < peaks[w*2] {
+				peaks[w*2] = v
+			}
+			if v > peaks[w*2+1] {
+				peaks[w*2+1] = v
+			}
+		}
+	}
+	return out, peaks
+}
@@ -0,0 +1,68 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import (
+	"math"
+	"testing"
+)
+
+func TestResampleWithPeaks(t *testing.T) {
+	in := sine(44100*2+123, 2, 3, 44100)
+	r := mustResampler(t, 2, 44100, 48000)
+	out, peaks := r.ResampleWithPeaks(in, 10)
+	assertEqualSamples(t, out, r.ResampleFloat64(in))
+
+	// 2 seconds and a bit of output make 21 windows of 4800 frames.
+	if len(peaks) != 21*2 {
+		t.Fatalf("got %d peak values, want 42", len(peaks))
+	}
+	for w := 0; w < 21; w++ {
+		lo, hi := math.Inf(1), math.Inf(-1)
+		for i := w * 4800 * 2; i < (w+1)*4800*2 && i < len(out); i++ {
+			lo, hi = math.Min(lo, out[i]), math.Max(hi, out[i])
+		}
+		if peaks[2*w] != lo || peaks[2*w+1] != hi {
+			t.Errorf("window %d: got %v, %v, want %v, %v", w, peaks[2*w], peaks[2*w+1], lo, hi)
+		}
+	}
+
+	if _, peaks := r.ResampleWithPeaks(in, 0); peaks != nil {
+		t.Error("expected nil peaks for a rate of 0")
+	}
+
+	// More peaks per second than frames give one window per frame, none empty.
+	out, peaks = mustResampler(t, 2, 4000, 1000).ResampleWithPeaks(in[:400], 5000)
+	if len(out) == 0 || len(peaks) != len(out) {
+		t.Fatalf("got %d peak values for %d frames, want one pair per frame", len(peaks), len(out)/2)
+	}
+	for f := 0; f < len(out)/2; f++ {
+		lo, hi := math.Min(out[2*f], out[2*f+1]), math.Max(out[2*f], out[2*f+1])
+		if peaks[2*f] != lo || peaks[2*f+1] != hi {
+			t.Errorf("frame %d: got %v, %v, want %v, %v", f, peaks[2*f], peaks[2*f+1], lo, hi)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import "math"
+
+// Corrects the pitch of a recording that was digitized with the wrong clock,
+// given a reference tone that should be expectedHz but measures as measuredHz.
+// The buffer is resampled from FromRate to the whole rate closest to
+// FromRate*measuredHz/expectedHz, so played at FromRate the tone lands on
+// expectedHz (to within a fraction of a cent at audio rates). FromRate stays
+// the real input rate, so options that work in Hz keep their frequencies.
+// Returns the corrected buffer, still at FromRate, or nil if either frequency
+// is not a positive number or the correction needs a rate beyond
+// MaxSampleRate.
+func (r *Resampler) CorrectPitch(data []float64, measuredHz, expectedHz float64) []float64 {
+	if !(measuredHz > 0) || !(expectedHz > 0) || math.IsInf(measuredHz, 0) || math.IsInf(expectedHz, 0) {
+		return nil
+	}
+
+	to := math.Round(float64(r.FromRate) * measuredHz / expectedHz)
+	if to < 1 || to > MaxSampleRate {
+		return nil
+	}
+	target := *r
+	target.ToRate = int(to)
+	return target.ResampleFloat64(data)
+}
+
+// Returns the indices of the samples at which a mono buffer crosses zero: every
+// index i where the sign of data[i] differs from the sign of the last nonzero
+// sample before it. Samples that are exactly zero do not count as crossings by
+// themselves.
+func ZeroCrossings(data []float64) []int {
+	var crossings []int
+	last := 0.0
+	for i, v := range data {
+		if v == 0 {
+			continue
+		}
+		if last != 0 && (v > 0) != (last > 0) {
+			crossings = append(crossings, i)
+		}
+		last = v
+	}
+	return crossings
+}
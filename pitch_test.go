@@ -0,0 +1,91 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCorrectPitch(t *testing.T) {
+	in := sine(44100, 1, 445, 44100)
+	r := mustResampler(t, 1, 44100, 44100)
+	out := r.CorrectPitch(in, 445, 440)
+	// 44100 * 445 / 440 rounds to 44601 Hz.
+	if want := mustResampler(t, 1, 44100, 44601).OutputLen(len(in)); len(out) != want {
+		t.Fatalf("got %d samples, want %d", len(out), want)
+	}
+	// Played back at FromRate, the tone is where it should be.
+	if amp, _ := tone(out[:44100], 1, 0, 440, 44100); amp < 0.79 {
+		t.Errorf("440 Hz has an amplitude of %v, want 0.8", amp)
+	}
+	if r.FromRate != 44100 || r.ToRate != 44100 {
+		t.Error("CorrectPitch changed the resampler")
+	}
+	for _, hz := range [][2]float64{{0, 440}, {445, math.NaN()}, {math.Inf(1), 440}, {1e9, 1}} {
+		if r.CorrectPitch(in, hz[0], hz[1]) != nil {
+			t.Errorf("expected nil for %v Hz measured against %v Hz", hz[0], hz[1])
+		}
+	}
+}
+
+func TestZeroCrossings(t *testing.T) {
+	got := ZeroCrossings([]float64{1, 2, -1, 0, -3, 0, 0, 4, 5, -0.5})
+	want := []int{2, 7, 9}
+	if len(got) != len(want) {
+		t.Fatalf("got crossings %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("got crossings %v, want %v", got, want)
+		}
+	}
+	if got := ZeroCrossings([]float64{0, 0, 1}); got != nil {
+		t.Errorf("got crossings %v for a buffer starting at zero", got)
+	}
+}
+
+// Returns the time in seconds of the nth zero crossing of a mono buffer,
+// interpolated linearly between the samples around it.
+func crossingTime(data []float64, n int, rate float64) float64 {
+	i := ZeroCrossings(data)[n]
+	a, b := data[i-1], data[i]
+	return (float64(i-1) + a/(a-b)) / rate
+}
+
+func TestWithZeroPhaseFilters(t *testing.T) {
+	in := sine(44100, 1, 220, 44100)
+	want := crossingTime(in, 10, 44100)
+	for _, zeroPhase := range []bool{true, false} {
+		r := mustResampler(t, 1, 44100, 8000, WithZeroPhaseFilters(zeroPhase))
+		r.AntiAlias = true
+		got := crossingTime(r.ResampleFloat64(in), 10, 8000)
+		// Within a tenth of an output sample.
+		if shifted := math.Abs(got-want) > 1.0/80000; shifted != !zeroPhase {
+			t.Errorf("zero-phase %v: crossing at %.6f s, input crosses at %.6f s", zeroPhase, got, want)
+		}
+	}
+}
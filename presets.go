@@ -0,0 +1,75 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+// Commonly used sample rates.
+const (
+	RateTelephony = 8000  // Narrowband telephony.
+	RateWideband  = 16000 // Wideband speech.
+	RateCD        = 44100 // Compact disc audio.
+	RateDAT       = 48000 // Digital audio tape, video and most audio interfaces.
+	RateHiRes     = 96000 // High resolution audio.
+)
+
+// Resamples a float64 audio buffer to RateCD, regardless of ToRate. Returns the
+// resampled buffer.
+func (r *Resampler) ResampleToCD(data []float64) []float64 {
+	return r.resampleTo(data, RateCD)
+}
+
+// Resamples a float64 audio buffer to RateDAT, regardless of ToRate. Returns
+// the resampled buffer.
+func (r *Resampler) ResampleTo48k(data []float64) []float64 {
+	return r.resampleTo(data, RateDAT)
+}
+
+func (r *Resampler) resampleTo(data []float64, rate int) []float64 {
+	target := *r
+	target.ToRate = rate
+	return target.ResampleFloat64(data)
+}
+
+// Resamples a float64 audio buffer to ToRate in two stages, going through
+// intermediateRate first (usually a common multiple of FromRate and ToRate).
+// The first stage applies the settings of the resampler (filters, gain and so
+// on); the second stage only interpolates, in the same mode. Both stages
+// low-pass the signal before downsampling, as with AntiAlias. Returns the
+// resampled buffer, or nil if intermediateRate is below 1 or above
+// MaxSampleRate, or if either stage would exceed the limit set with
+// WithMaxOutputSamples.
+func (r *Resampler) ResampleViaIntermediate(data []float64, intermediateRate int) []float64 {
+	if intermediateRate < 1 || intermediateRate > MaxSampleRate {
+		return nil
+	}
+
+	up := *r
+	up.ToRate = intermediateRate
+	up.AntiAlias = true
+	down := r.interpolateOnly(r.Channels, intermediateRate, r.ToRate)
+	down.AntiAlias = true
+	down.zeroPhase = r.zeroPhase
+	return down.ResampleFloat64(up.ResampleFloat64(data))
+}
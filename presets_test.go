@@ -0,0 +1,87 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRatePresets(t *testing.T) {
+	in := sine(1000, 2, 440, 22050)
+	r := mustResampler(t, 2, 22050, 8000)
+	assertEqualSamples(t, r.ResampleToCD(in), mustResampler(t, 2, 22050, RateCD).ResampleFloat64(in))
+	assertEqualSamples(t, r.ResampleTo48k(in), mustResampler(t, 2, 22050, RateDAT).ResampleFloat64(in))
+	if r.ToRate != 8000 {
+		t.Fatalf("presets changed ToRate to %d", r.ToRate)
+	}
+}
+
+func TestResampleViaIntermediate(t *testing.T) {
+	in := sine(4410, 1, 440, 44100)
+	r := mustResampler(t, 1, 44100, 48000, WithGain(0.5))
+	out := r.ResampleViaIntermediate(in, 96000)
+	if len(out) != r.OutputLen(len(in)) {
+		t.Fatalf("got %d samples, want %d", len(out), r.OutputLen(len(in)))
+	}
+	// The gain is applied by the first stage only.
+	if amp, _ := tone(out[500:4500], 1, 0, 440, 48000); math.Abs(amp-0.4) > 0.01 {
+		t.Errorf("got an amplitude of %v, want 0.4", amp)
+	}
+	for _, rate := range []int{0, MaxSampleRate + 1} {
+		if r.ResampleViaIntermediate(in, rate) != nil {
+			t.Errorf("expected nil for an intermediate rate of %d", rate)
+		}
+	}
+}
+
+// Returns the THD+N of a 15 kHz tone in 48 kHz output: the energy of
+// everything but the tone relative to the tone, in dB.
+func thdN(out []float64) float64 {
+	// Half a second, a whole number of cycles, from the middle
+	segment := out[12000:36000]
+	amp, phase := tone(segment, 1, 0, 15000, 48000)
+	fitted := make([]float64, len(segment))
+	residual := make([]float64, len(segment))
+	for i, v := range segment {
+		fitted[i] = amp * math.Cos(2*math.Pi*15000*float64(i)/48000-phase)
+		residual[i] = v - fitted[i]
+	}
+	return relativeDB(residual, fitted)
+}
+
+// A high tone converted directly from 44.1 kHz leaves strong images and
+// aliases; going through 96 kHz with both stages filtered removes most of
+// them.
+func TestResampleViaIntermediateDistortion(t *testing.T) {
+	in := sine(44100, 1, 15000, 44100)
+	r := mustResampler(t, 1, 44100, 48000)
+	direct := thdN(r.ResampleFloat64(in))
+	via := thdN(r.ResampleViaIntermediate(in, 96000))
+	if via > direct-15 || via > -30 {
+		t.Errorf("THD+N is %.1f dB through 96 kHz and %.1f dB direct, want at least 15 dB better and below -30 dB", via, direct)
+	}
+}
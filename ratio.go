@@ -0,0 +1,66 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import "math"
+
+// Returns the fraction num/den closest to x (x > 0) with both parts no larger
+// than limit, using continued fractions.
+func rationalApprox(x float64, limit int) (num, den int) {
+	if x >= float64(limit) {
+		// Not even the first term fits, use the largest representable ratio
+		return limit, 1
+	}
+	// Convergents h/k of the continued fraction of x
+	h0, h1 := 0, 1
+	k0, k1 := 1, 0
+	best := [2]int{1, 1}
+	rest := x
+	for i := 0; i < 64; i++ {
+		a := math.Floor(rest)
+		if a > float64(limit) {
+			break
+		}
+		h := int(a)*h1 + h0
+		k := int(a)*k1 + k0
+		if h > limit || k > limit {
+			break
+		}
+		h0, h1 = h1, h
+		k0, k1 = k1, k
+		best = [2]int{h, k}
+		frac := rest - a
+		if frac < 1e-12 {
+			break
+		}
+		rest = 1 / frac
+	}
+	if best[0] == 0 {
+		// x is below 1/limit, use the smallest representable ratio
+		return 1, limit
+	}
+	return best[0], best[1]
+}
@@ -0,0 +1,81 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNewResamplerRatio(t *testing.T) {
+	tests := []struct {
+		ratio    float64
+		from, to int
+	}{
+		{1.5, 2, 3},
+		{44100.0 / 48000, 160, 147},
+		{1, 1, 1},
+		{2e7, 1, MaxSampleRate},
+		{1e-9, MaxSampleRate, 1},
+	}
+	for _, tt := range tests {
+		r, err := NewResamplerRatio(2, tt.ratio)
+		if err != nil {
+			t.Fatalf("ratio %v: %v", tt.ratio, err)
+		}
+		if r.FromRate != tt.from || r.ToRate != tt.to || r.Channels != 2 {
+			t.Errorf("ratio %v: got %d/%d with %d channels, want %d/%d with 2", tt.ratio, r.ToRate, r.FromRate, r.Channels, tt.to, tt.from)
+		}
+	}
+	for _, ratio := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		if _, err := NewResamplerRatio(1, ratio); err == nil {
+			t.Errorf("expected an error for a ratio of %v", ratio)
+		}
+	}
+}
+
+func TestArbitraryRatios(t *testing.T) {
+	ratios := []float64{1.0293, math.Pow(2, 1.0/12), math.Pi, 1 / math.E, 0.999999, 7.3e-3, 123.456}
+	for _, ratio := range ratios {
+		r, err := NewResamplerRatio(1, ratio)
+		if err != nil {
+			t.Fatalf("ratio %v: %v", ratio, err)
+		}
+		if got := float64(r.ToRate) / float64(r.FromRate); math.Abs(got-ratio)/ratio > 1e-9 {
+			t.Errorf("ratio %v: got %d/%d = %v", ratio, r.ToRate, r.FromRate, got)
+		}
+
+		// Steps that do not divide the input evenly stay inside it.
+		in := sine(1001, 1, 3, 1000)
+		out := r.ResampleFloat64(in)
+		if want := float64(len(in)) * ratio; math.Abs(float64(len(out))-want) > 1 {
+			t.Errorf("ratio %v: got %d samples, want about %.1f", ratio, len(out), want)
+		}
+	}
+	if r, err := NewResamplerRatio(1, 1.0293); err != nil || r.FromRate != 10000 || r.ToRate != 10293 {
+		t.Errorf("1.0293 should be exactly 10293/10000")
+	}
+}
@@ -26,25 +26,82 @@
 package gomplerate
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math"
+	"runtime"
+	"sync"
 )
 
+// Returned by the error-returning variants when the input is too short for the
+// resampling ratio to produce any output.
+var ErrEmptyOutput = errors.New("resampling produces no output samples")
+
+// Returned by the error-returning variants when the output would be longer than
+// the limit set with WithMaxOutputSamples.
+var ErrOutputTooLarge = errors.New("resampling output exceeds the maximum amount of samples")
+
+// The highest sample rate accepted by NewResampler. Anything above it is almost
+// certainly the result of a bad conversion (such as int(math.NaN())).
+const MaxSampleRate = 10_000_000
+
 type Resampler struct {
 	FromRate int // The original audio sample rate.
 	ToRate   int // The resampled audio sample rate.
 	Channels int // The amount of channels.
+
+	// Forces output samples whose whole interpolation window is zero to be
+	// exactly 0.0, so digital silence stays bit-for-bit silent.
+	PreserveSilence bool
+
+	// Low-pass filters every channel just below the new Nyquist frequency before
+	// downsampling, so content the output rate cannot represent is removed
+	// instead of folding back as aliasing. The filter is an 8th order
+	// Butterworth, which delays the signal slightly (see WithZeroPhaseFilters).
+	// Has no effect when upsampling. Set it before creating a stream. Decimation
+	// by a factor of 100 or more, such as 48 kHz to 1 Hz, is always filtered
+	// like this, since the output would otherwise point-sample the input; there
+	// the delay of the filter is seconds long, so trend extraction should
+	// combine it with WithZeroPhaseFilters.
+	AntiAlias bool
+
+	// The most goroutines that resample channels in parallel. 0 picks
+	// GOMAXPROCS for long buffers and resamples short ones serially; 1 always
+	// resamples serially. The output does not depend on it. The low-memory
+	// path and ResampleFloat64Into always resample one channel at a time.
+	MaxConcurrency int
+
+	transfer func(float64) float64 // Applied before integer quantization.
+	rawInt   bool                  // Interpolate integer samples without normalizing.
+	layout   ChannelLayout         // Speaker position of every channel.
+	intScale float64               // Custom integer full scale, 0 for the default.
+	rounding RoundMode             // Rounding of float samples converted to integers.
+
+	lowMemory       bool            // Resample one channel at a time into the output.
+	outputLayout    OutputLayout    // Layout returned by ResampleFloat64Layout.
+	arena           [][]float64     // Reused channel split buffers.
+	gate            *noiseGate      // Applied to input channels, copied per channel.
+	eq              []biquad        // EQ bands applied to input channels, copied per channel.
+	eqBands         []Band          // The bands eq was designed from, to redesign it for another rate.
+	mode            InterpMode      // How output samples are interpolated.
+	sincWindow      SincWindow      // Window of the InterpSinc kernel.
+	overshootGuard  float64         // Level from which windows are interpolated linearly, 0 for never.
+	generation      int             // Bumped by Reset, so streams know to drop their state.
+	ctx             context.Context // Cancels interpolation, set on per-call copies only.
+	anchorEndpoints bool            // Copy the first and last input frames to the output.
+	integerDelays   bool            // Only pick existing samples, never interpolate.
+	maxOutput       int             // Output length limit, 0 for no limit.
+	dcBlock         bool            // Remove DC from every output channel.
+	zeroPhase       bool            // Run input filters forward and backward.
+	scratch         channelScratch  // Reused buffers of ResampleFloat64Into.
+	gain            float64         // Scale of every output sample, 0 for unity.
+	envelope        *gainEnvelope   // Gain over the input frames, set on per-call copies only.
 }
 
-func NewResampler(channels, inputRate, outputRate int) (*Resampler, error) {
-	if channels < 1 {
-		return nil, fmt.Errorf("at least 1 channel is required (have %d)", channels)
-	}
-	if inputRate < 1 {
-		return nil, fmt.Errorf("input sample rate must be bigger than 0 (got %d)", inputRate)
-	}
-	if outputRate < 1 {
-		return nil, fmt.Errorf("output sample rate must be bigger than 0 (got %d)", outputRate)
+func NewResampler(channels, inputRate, outputRate int, opts ...Option) (*Resampler, error) {
+	if err := validateConfig(channels, inputRate, outputRate); err != nil {
+		return nil, err
 	}
 
 	resampler := &Resampler{
@@ -52,17 +109,144 @@ func NewResampler(channels, inputRate, outputRate int) (*Resampler, error) {
 		ToRate:   outputRate,
 		Channels: channels,
 	}
+	for _, opt := range opts {
+		if err := opt(resampler); err != nil {
+			return nil, err
+		}
+	}
 
 	return resampler, nil
 }
 
-// Resamples a float64 audio buffer. Returns the resampled buffer.
+// Creates a resampler from the ratio of the output rate to the input rate, for
+// when the rates themselves are not known. FromRate and ToRate are set to the
+// closest fraction ToRate/FromRate of ratio with both parts no larger than
+// MaxSampleRate, so they are only meaningful as a ratio; options that work in
+// time units (such as WithNoiseGate) use them as rates in Hz. Ratios beyond
+// MaxSampleRate or below its inverse are clamped to them.
+func NewResamplerRatio(channels int, ratio float64, opts ...Option) (*Resampler, error) {
+	if !(ratio > 0) || math.IsInf(ratio, 1) {
+		return nil, fmt.Errorf("ratio must be a positive number (got %v)", ratio)
+	}
+	to, from := rationalApprox(ratio, MaxSampleRate)
+	return NewResampler(channels, from, to, opts...)
+}
+
+// Returns a resampler from fromRate to toRate with the given channel count that
+// only interpolates, in the interpolation mode and sinc window and with the
+// concurrency, context and output limit of r, but none of its filters, gain or
+// other sample processing.
+func (r *Resampler) interpolateOnly(channels, fromRate, toRate int) *Resampler {
+	return &Resampler{
+		FromRate:       fromRate,
+		ToRate:         toRate,
+		Channels:       channels,
+		MaxConcurrency: r.MaxConcurrency,
+		mode:           r.mode,
+		sincWindow:     r.sincWindow,
+		ctx:            r.ctx,
+		maxOutput:      r.maxOutput,
+	}
+}
+
+// Returns an independent copy of the resampler with the same configuration.
+func (r *Resampler) Clone() *Resampler {
+	clone := *r
+	clone.layout = append(ChannelLayout(nil), r.layout...)
+	clone.arena = nil
+	clone.scratch = channelScratch{}
+	return &clone
+}
+
+// Changes the channel count and rates of the resampler, validating them like
+// NewResampler, and drops the buffered state of its streams like Reset. The
+// configured options are kept; the noise gate and EQ are redesigned for the new
+// input rate. Returns an error, leaving the resampler unchanged, if the new
+// configuration is invalid, conflicts with a configured channel layout or
+// integer delays, or puts an EQ band at or above the new Nyquist frequency.
+func (r *Resampler) Reconfigure(channels, inputRate, outputRate int) error {
+	if err := r.validateReconfigure(channels, inputRate, outputRate); err != nil {
+		return err
+	}
+
+	if r.eqBands != nil {
+		// Checked by validateReconfigure already
+		r.eq, _ = newEQ(float64(inputRate), r.eqBands)
+	}
+	if r.gate != nil {
+		s := r.gate.settings
+		r.gate = newNoiseGate(float64(inputRate), s[0], s[1], s[2])
+	}
+	if channels != r.Channels {
+		r.arena = nil
+	}
+	r.FromRate = inputRate
+	r.ToRate = outputRate
+	r.Channels = channels
+	r.Reset()
+	return nil
+}
+
+// Checks whether the resampler can be reconfigured to the given channel count
+// and rates.
+func (r *Resampler) validateReconfigure(channels, inputRate, outputRate int) error {
+	if err := validateConfig(channels, inputRate, outputRate); err != nil {
+		return err
+	}
+	if r.layout != nil && len(r.layout) != channels {
+		return fmt.Errorf("channel layout has %d channels, but the resampler would have %d", len(r.layout), channels)
+	}
+	if r.integerDelays && inputRate%outputRate != 0 && outputRate%inputRate != 0 {
+		return fmt.Errorf("%d Hz to %d Hz is not an integer ratio, so it cannot be done with integer delays", inputRate, outputRate)
+	}
+	if r.eqBands != nil {
+		if _, err := newEQ(float64(inputRate), r.eqBands); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Drops the buffered state of every stream created from the resampler, without
+// changing its configuration. Each stream starts over with its next call, as if
+// it had just been created.
+func (r *Resampler) Reset() {
+	r.generation++
+}
+
+func validateConfig(channels, inputRate, outputRate int) error {
+	if channels < 1 {
+		return fmt.Errorf("at least 1 channel is required (have %d)", channels)
+	}
+	if inputRate < 1 {
+		return fmt.Errorf("input sample rate must be bigger than 0 (got %d)", inputRate)
+	}
+	if outputRate < 1 {
+		return fmt.Errorf("output sample rate must be bigger than 0 (got %d)", outputRate)
+	}
+	if inputRate > MaxSampleRate {
+		return fmt.Errorf("input sample rate must not exceed %d (got %d)", MaxSampleRate, inputRate)
+	}
+	if outputRate > MaxSampleRate {
+		return fmt.Errorf("output sample rate must not exceed %d (got %d)", MaxSampleRate, outputRate)
+	}
+	return nil
+}
+
+// Resamples a float64 audio buffer. Returns the resampled buffer. Every channel
+// is interpolated at exactly the same input positions, so time delays between
+// channels (even fractions of a sample, as in microphone arrays) are preserved.
+// Returns nil if the output would exceed the limit set with WithMaxOutputSamples.
 func (resampler *Resampler) ResampleFloat64(data []float64) []float64 {
-	if len(data) == 0 {
+	if len(data) == 0 || resampler.outputTooLarge(len(data)) {
 		return nil
 	}
 	if resampler.FromRate == resampler.ToRate {
-		return data[:]
+		return resampler.passThrough(data)
+	}
+	if resampler.OutputLen(len(data)) == 0 {
+		// Nothing to interpolate, so skip the kernel and filter setup
+		return []float64{}
 	}
 	/*
 		// The audio must have at least 4 samples
@@ -71,97 +255,697 @@ func (resampler *Resampler) ResampleFloat64(data []float64) []float64 {
 		}
 	*/
 
-	// Split channels
-	channels := make([][]float64, resampler.Channels)
-	for i := 0; i < len(data); i++ {
-		channelIdx := i % resampler.Channels
-		channels[channelIdx] = append(channels[channelIdx], data[i])
+	if resampler.lowMemory {
+		return resampler.resampleLowMemory(data)
+	}
+	if resampler.Channels == 1 {
+		// A single channel needs no splitting or interleaving
+		resampled := resampler.resamplePlanar([][]float64{data}, resampler.OutputLen(len(data)))
+		if resampled == nil {
+			return nil
+		}
+		return resampled[0]
+	}
+	channels := resampler.splitChannelsScratch(data)
+	return resampler.resampleSplit(channels, len(data))
+}
+
+// Resamples a float64 audio buffer. Unlike ResampleFloat64, it rejects buffers
+// that do not hold a whole amount of frames with an error. Returns the
+// resampled buffer, or ErrEmptyOutput if it would contain no samples (such as
+// for empty input) and ErrOutputTooLarge if it would exceed the limit set with
+// WithMaxOutputSamples.
+func (r *Resampler) ResampleFloat64E(data []float64) ([]float64, error) {
+	if len(data)%r.Channels != 0 {
+		return nil, fmt.Errorf("buffer of %d samples is not a whole amount of %d-channel frames", len(data), r.Channels)
+	}
+	if r.OutputLen(len(data)) == 0 {
+		return nil, ErrEmptyOutput
+	}
+	if r.outputTooLarge(len(data)) {
+		return nil, ErrOutputTooLarge
+	}
+	return r.ResampleFloat64(data), nil
+}
+
+// Resamples a float64 audio buffer and reports whether resampling changed it.
+// changed is false when FromRate equals ToRate, in which case out is data itself
+// and callers can skip re-encoding it.
+func (r *Resampler) ResampleFloat64Changed(data []float64) (out []float64, changed bool) {
+	return r.ResampleFloat64(data), r.FromRate != r.ToRate
+}
+
+// Resamples a float64 audio buffer into dst, which must hold at least
+// OutputLen(len(src)) samples, and returns the amount of samples written. The
+// channel buffer is kept on the resampler and reused, so once it has grown to
+// the longest channel, calls do not allocate (unless input or output filters
+// are configured). This makes the method unsafe for concurrent use. Returns
+// ErrOutputTooLarge if the output would exceed the limit set with
+// WithMaxOutputSamples.
+func (r *Resampler) ResampleFloat64Into(dst, src []float64) (int, error) {
+	n := r.OutputLen(len(src))
+	if len(dst) < n {
+		return 0, fmt.Errorf("destination holds %d samples, but the output needs %d", len(dst), n)
+	}
+	if r.outputTooLarge(len(src)) {
+		return 0, ErrOutputTooLarge
+	}
+	if r.FromRate == r.ToRate {
+		copy(dst, src)
+		r.applyGain(dst[:n])
+		return n, nil
+	}
+	r.resampleInterleavedInto(dst[:n], src, &r.scratch)
+	return n, nil
+}
+
+// Resamples a float64 audio buffer like ResampleFloat64, but stops early once
+// ctx is done. The context is checked every few thousand output samples of each
+// channel. Returns the resampled buffer, or the error of ctx if it was done
+// before resampling finished, in which case the partial output is discarded.
+func (r *Resampler) ResampleFloat64Context(ctx context.Context, data []float64) ([]float64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	target := *r
+	target.ctx = ctx
+	out := target.ResampleFloat64(data)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// How many output samples of a channel are interpolated between checks of the
+// context of ResampleFloat64Context.
+const cancelCheckInterval = 4096
+
+// Reports whether the context the resampler runs under is done.
+func (r *Resampler) cancelled() bool {
+	return r.ctx != nil && r.ctx.Err() != nil
+}
+
+// Resamples the readable region of a ring buffer that wraps around the end of
+// its backing array: seg1 is followed by seg2 in time, and a frame may be split
+// between them. The segments are not concatenated first. Returns the resampled
+// buffer.
+func (r *Resampler) ResampleRing(seg1, seg2 []float64) []float64 {
+	if len(seg1)+len(seg2) == 0 || r.outputTooLarge(len(seg1)+len(seg2)) {
+		return nil
+	}
+	if r.FromRate == r.ToRate {
+		out := append(append(make([]float64, 0, len(seg1)+len(seg2)), seg1...), seg2...)
+		r.applyGain(out)
+		return out
+	}
+	channels := r.splitChannelsScratch(seg1, seg2)
+	return r.resampleSplit(channels, len(seg1)+len(seg2))
+}
+
+// Reports whether resampling inputLen samples would produce more output than the
+// limit set with WithMaxOutputSamples.
+func (r *Resampler) outputTooLarge(inputLen int) bool {
+	return r.maxOutput > 0 && r.OutputLen(inputLen) > r.maxOutput
+}
+
+// Returns the length of the buffer ResampleFloat64 produces for an interleaved
+// input of inputLen samples: the input frame count times ToRate/FromRate,
+// rounded to the nearest frame. A trailing partial frame counts as a frame.
+func (r *Resampler) OutputLen(inputLen int) int {
+	if inputLen <= 0 {
+		return 0
+	}
+	if r.FromRate == r.ToRate {
+		return inputLen
+	}
+	// In 64 bits, since the product overflows a 32-bit int after a few seconds
+	// of audio.
+	frames := int64((inputLen + r.Channels - 1) / r.Channels)
+	return int((2*frames*int64(r.ToRate)+int64(r.FromRate))/(2*int64(r.FromRate))) * r.Channels
+}
+
+// Resamples a float64 audio buffer to two output rates at once, splitting the
+// channels only one time. Returns nil for an output whose rate is below 1 or
+// above MaxSampleRate, or that would exceed the limit set with
+// WithMaxOutputSamples.
+func (r *Resampler) ResampleDual(data []float64, rate1, rate2 int) (out1, out2 []float64) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	channels := r.splitChannelsScratch(data)
+	resampleTo := func(rate int) []float64 {
+		if rate < 1 || rate > MaxSampleRate {
+			return nil
+		}
+		if rate == r.FromRate {
+			return r.passThrough(data)
+		}
+		target := *r
+		target.ToRate = rate
+		if target.outputTooLarge(len(data)) {
+			return nil
+		}
+		return target.resampleSplit(channels, len(data))
+	}
+	return resampleTo(rate1), resampleTo(rate2)
+}
+
+// Sets the slices, one per channel, that input channels are split into before
+// resampling. They are reused (and grown as needed) by every call instead of
+// allocating new ones, which makes the resampler unsafe for concurrent use.
+// Pass nil to go back to allocating.
+func (r *Resampler) SetChannelArena(arena [][]float64) error {
+	if arena != nil && len(arena) != r.Channels {
+		return fmt.Errorf("channel arena has %d channels, but the resampler has %d", len(arena), r.Channels)
+	}
+	r.arena = arena
+	return nil
+}
+
+// Like splitChannels, but splits into the channel arena if one is set. The
+// arena is skipped on per-call copies of the resampler with a different
+// channel count (such as in ResampleAudio). The result must not outlive the
+// call it is used in.
+func (resampler *Resampler) splitChannelsScratch(segments ...[]float64) [][]float64 {
+	if len(resampler.arena) != resampler.Channels {
+		return resampler.splitChannels(segments...)
+	}
+	for c := range resampler.arena {
+		resampler.arena[c] = resampler.arena[c][:0]
+	}
+	return splitInto(resampler.arena, segments...)
+}
+
+// Splits an interleaved buffer into one slice per channel. The buffer may be
+// given as several consecutive segments.
+func (resampler *Resampler) splitChannels(segments ...[]float64) [][]float64 {
+	return splitInto(make([][]float64, resampler.Channels), segments...)
+}
+
+// Splits the interleaved segments into channels, which holds one slice per
+// channel. The slices are reused if they have the capacity, and allocated at
+// their final length otherwise.
+func splitInto(channels [][]float64, segments ...[]float64) [][]float64 {
+	total := 0
+	for _, data := range segments {
+		total += len(data)
+	}
+	for c := range channels {
+		// A trailing partial frame only reaches the first channels
+		n := (total - c + len(channels) - 1) / len(channels)
+		if cap(channels[c]) < n {
+			channels[c] = make([]float64, n)
+		}
+		channels[c] = channels[c][:n]
+	}
+
+	i := 0
+	for _, data := range segments {
+		for _, v := range data {
+			channels[i%len(channels)][i/len(channels)] = v
+			i++
+		}
+	}
+	return channels
+}
+
+// Resamples one channel at a time straight into the interleaved output, reusing
+// a single scratch buffer for the channel being processed.
+func (resampler *Resampler) resampleLowMemory(data []float64) []float64 {
+	longest := (len(data) + resampler.Channels - 1) / resampler.Channels
+	resampled := make([]float64, resampler.OutputLen(len(data)))
+	resampler.resampleInterleavedInto(resampled, data, &channelScratch{channel: make([]float64, 0, longest)})
+	return resampled
+}
+
+// Buffers for resampling one channel at a time, grown as needed.
+type channelScratch struct {
+	channel []float64 // The samples of the channel being resampled.
+	window  []float64 // The interpolation window.
+}
+
+// Resamples an interleaved buffer one channel at a time into resampled, which
+// must hold exactly OutputLen(len(data)) samples, using the buffers in
+// buffers.
+func (resampler *Resampler) resampleInterleavedInto(resampled, data []float64, buffers *channelScratch) {
+	frames := len(resampled) / resampler.Channels
+	checkLengths("interleaved", resampler.OutputLen(len(data)), len(resampled), frames*resampler.Channels)
+	if frames == 0 {
+		return
+	}
+
+	for c := 0; c < resampler.Channels; c++ {
+		scratch := buffers.channel[:0]
+		for i := c; i < len(data); i += resampler.Channels {
+			scratch = append(scratch, data[i])
+		}
+		buffers.channel = scratch
+		written := resampler.resampleChannelInto(resampled[c:], resampler.Channels, resampler.filterChannel(scratch), &buffers.window)
+		if written == 0 {
+			continue
+		}
+		// Like resamplePlanar, channels that came out shorter hold their
+		// last sample
+		for f := written; f < frames; f++ {
+			resampled[f*resampler.Channels+c] = resampled[(written-1)*resampler.Channels+c]
+		}
+		resampler.filterOutputChannel(resampled[c:], resampler.Channels)
+		// After the output filters, which would change them again
+		if resampler.anchorEndpoints {
+			last := float64(frames-1) * float64(resampler.FromRate) / float64(resampler.ToRate)
+			resampled[c] = resampler.gainAt(0) * scratch[0]
+			resampled[(frames-1)*resampler.Channels+c] = resampler.gainAt(last) * scratch[len(scratch)-1]
+		}
+	}
+}
+
+// Resamples already split channels and interleaves them back together.
+// inputLen is the length of the interleaved input; the result is trimmed, or
+// padded by holding the last frame, to exactly OutputLen(inputLen) samples.
+func (resampler *Resampler) resampleSplit(channels [][]float64, inputLen int) []float64 {
+	frames := resampler.OutputLen(inputLen) / resampler.Channels
+	resampledData := resampler.resamplePlanar(channels, frames)
+	if resampledData == nil {
+		return nil
 	}
 
-	resampled := make(
-		[]float64,
-		int((float64(len(data))/float64(resampler.FromRate))*float64(resampler.ToRate)),
-	)
+	resampled := interleave(resampledData)
+	checkLengths("interleaved", resampler.OutputLen(inputLen), frames*resampler.Channels, len(resampled))
+	return resampled
+}
 
-	// Resample channels
+// Resamples already split channels into exactly frames samples each.
+func (resampler *Resampler) resamplePlanar(channels [][]float64, frames int) [][]float64 {
 	resampledData := make([][]float64, len(channels))
-	for c := 0; c < len(channels); c++ {
+	resampler.forEachChannel(channels, func(c int) {
 		resampledData[c] = resampler.resampleChannelData(channels[c])
+	})
+	if resampler.cancelled() {
+		return nil
 	}
 
-	for i := 0; i < len(resampled); i++ {
-		dataIdx := i / resampler.Channels
-		dataLen := len(resampledData[i%len(channels)])
-		if dataLen == 0 {
+	// Channels that came out shorter hold their last sample, like the last
+	// frame of the input is held past its end
+	for c, data := range resampledData {
+		if len(data) == frames {
 			continue
 		}
-		if dataIdx > dataLen-1 {
-			dataIdx = dataLen - 1
+		fitted := make([]float64, frames)
+		copy(fitted, data)
+		if len(data) > 0 {
+			for i := len(data); i < frames; i++ {
+				fitted[i] = data[len(data)-1]
+			}
 		}
-		if dataIdx < 0 {
-			dataIdx = 0
+		resampledData[c] = fitted
+	}
+
+	for _, data := range resampledData {
+		checkLengths("planar channel", frames, frames, len(data))
+		resampler.filterOutputChannel(data, 1)
+	}
+	// After the output filters, which would change them again
+	if resampler.anchorEndpoints && frames > 0 {
+		for c, data := range channels {
+			if len(data) > 0 {
+				last := float64(frames-1) * float64(resampler.FromRate) / float64(resampler.ToRate)
+				resampledData[c][0] = resampler.gainAt(0) * data[0]
+				resampledData[c][frames-1] = resampler.gainAt(last) * data[len(data)-1]
+			}
 		}
-		resampled[i] = resampledData[i%len(channels)][dataIdx]
 	}
+	return resampledData
+}
 
-	return resampled
+// Channels shorter than this are resampled serially when MaxConcurrency is 0,
+// since starting goroutines would cost more than it saves.
+const parallelMinSamples = 1 << 14
+
+// Calls fn for the index of every channel, spread over up to MaxConcurrency
+// goroutines. A panic in fn is re-raised in the calling goroutine once all
+// channels are done.
+func (r *Resampler) forEachChannel(channels [][]float64, fn func(c int)) {
+	workers := r.MaxConcurrency
+	if workers == 0 {
+		workers = runtime.GOMAXPROCS(0)
+		if len(channels) == 0 || len(channels[0]) < parallelMinSamples {
+			workers = 1
+		}
+	}
+	if workers > len(channels) {
+		workers = len(channels)
+	}
+	if workers <= 1 {
+		for c := range channels {
+			fn(c)
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	panics := make([]interface{}, workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			defer func() { panics[w] = recover() }()
+			for c := w; c < len(channels); c += workers {
+				fn(c)
+			}
+		}(w)
+	}
+	wg.Wait()
+	for _, p := range panics {
+		if p != nil {
+			panic(p)
+		}
+	}
 }
 
-// Resamples an int16 audio buffer. Returns the resampled buffer.
+// Resamples an int16 audio buffer. Returns the resampled buffer, or nil if it
+// would exceed the limit set with WithMaxOutputSamples.
 func (r *Resampler) ResampleInt16(data []int16) []int16 {
-	if len(data) == 0 {
+	if len(data) == 0 || r.outputTooLarge(len(data)) {
 		return nil
 	}
+	if r.integerOnly() {
+		return r.pickInt16(data)
+	}
 
-	// → float64 in (‑1 … +1)
-	f := 1.0 / 32768.0 // use 32768 so −32768 maps to −1.0
 	f64 := make([]float64, len(data))
 	for i, v := range data {
-		f64[i] = float64(v) * f
+		f64[i] = r.fromInt16(v)
 	}
 
 	outF64 := r.ResampleFloat64(f64)
 
-	// ← int16 with hard‑limit saturation
 	out := make([]int16, len(outF64))
 	for i, v := range outF64 {
-		if v > 1 {
-			v = 1
+		out[i] = r.toInt16(v)
+	}
+	return out
+}
+
+// Resamples a float64 audio buffer in float64 and returns the result as
+// float32. The samples are narrowed while interleaving the resampled channels,
+// so no interleaved float64 output is built.
+func (r *Resampler) ResampleToFloat32(data []float64) []float32 {
+	if r.FromRate == r.ToRate {
+		if len(data) == 0 || r.outputTooLarge(len(data)) {
+			return nil
+		}
+		out := make([]float32, len(data))
+		for i, v := range data {
+			out[i] = float32(v * r.outputGain())
+		}
+		return out
+	}
+
+	channels := r.resampleFloat64Planar(data)
+	if channels == nil {
+		return nil
+	}
+	out := make([]float32, len(channels[0])*r.Channels)
+	for i := range out {
+		out[i] = float32(channels[i%r.Channels][i/r.Channels])
+	}
+	return out
+}
+
+// Reports whether the integer methods can skip the float conversion entirely:
+// with WithIntegerDelays every output sample is a copy of an input sample, so
+// unless something alters the samples on the way, they can be picked directly
+// and the result is bit-exact.
+func (r *Resampler) integerOnly() bool {
+	return r.integerDelays && r.gain == 0 && r.transfer == nil && r.inputFilters() == nil && r.outputFilters() == nil
+}
+
+// Resamples an int16 audio buffer by picking the input sample at every output
+// position, in integer arithmetic. Gives the same result as the float path.
+func (r *Resampler) pickInt16(data []int16) []int16 {
+	out := make([]int16, r.OutputLen(len(data)))
+	frames := len(out) / r.Channels
+	step := float64(r.FromRate) / float64(r.ToRate)
+	for c := 0; c < r.Channels && c < len(data); c++ {
+		// The last input frame of the channel, which is held past its end
+		last := (len(data)-1-c)/r.Channels*r.Channels + c
+		for f := 0; f < frames; f++ {
+			i := int(float64(f)*step)*r.Channels + c
+			if i > last {
+				i = last
+			}
+			out[f*r.Channels+c] = data[i]
 		}
-		if v < -1 {
-			v = -1
+		if r.anchorEndpoints && frames > 0 {
+			out[(frames-1)*r.Channels+c] = data[last]
 		}
-		out[i] = int16(math.Round(v * 32767)) // avoid wrap‑around
 	}
 	return out
 }
 
-func (resampler *Resampler) resampleChannelData(data []float64) []float64 {
-	// Need at least 16 samples to resample a channel
-	if len(data) <= 16 {
-		return make([]float64, len(data))
+// Resamples a float32 audio buffer with the same interpolation as
+// ResampleFloat64. Returns the resampled buffer, or nil if it would exceed the
+// limit set with WithMaxOutputSamples.
+func (r *Resampler) ResampleFloat32(data []float32) []float32 {
+	if len(data) == 0 || r.outputTooLarge(len(data)) {
+		return nil
+	}
+	if r.FromRate == r.ToRate && r.gain == 0 {
+		return data[:]
+	}
+
+	f64 := make([]float64, len(data))
+	for i, v := range data {
+		f64[i] = float64(v)
+	}
+
+	outF64 := r.ResampleFloat64(f64)
+
+	out := make([]float32, len(outF64))
+	for i, v := range outF64 {
+		out[i] = float32(v)
 	}
+	return out
+}
 
-	// The samples we can use to resample
-	availSamples := len(data) - 16
+// Converts an int16 sample to float64 in (‑1 … +1).
+func (r *Resampler) fromInt16(v int16) float64 {
+	return float64(v) / r.int16Scale()
+}
 
-	// The resample step between new samples
-	channelFrom := float64(resampler.FromRate)
-	channelTo := float64(resampler.ToRate)
-	step := channelFrom / channelTo
+// Converts a float64 sample back to int16 with hard‑limit saturation.
+func (r *Resampler) toInt16(v float64) int16 {
+	if r.transfer != nil {
+		v = r.transfer(v)
+	}
+	// Scale back by the same factor used on the way in, so values (DC in
+	// particular) survive the round trip exactly, and clamp to avoid wrap‑around
+	return int16(r.roundClamp(v*r.int16Scale(), math.MinInt16, math.MaxInt16))
+}
 
-	output := make([]float64, int(math.Ceil(float64(availSamples)/step)))
+// Returns the factor between int16 samples and their float64 value.
+func (r *Resampler) int16Scale() float64 {
+	return r.integerScale(32768) // so −32768 maps to −1.0
+}
 
-	// Resample each position from x0
-	i := 0
-	for x := step; x < float64(availSamples); x += step {
+// Returns the factor between integer samples and their float64 value, given
+// the default full scale of the integer format.
+func (r *Resampler) integerScale(fullScale float64) float64 {
+	switch {
+	case r.rawInt:
+		return 1
+	case r.intScale != 0:
+		return r.intScale
+	default:
+		return fullScale
+	}
+}
+
+// Rounds v with the configured RoundMode and clamps it to [min, max].
+func (r *Resampler) roundClamp(v, min, max float64) float64 {
+	switch r.rounding {
+	case RoundTowardZero:
+		v = math.Trunc(v)
+	case RoundTowardNegInf:
+		v = math.Floor(v)
+	default:
+		v = math.Round(v)
+	}
+	return math.Max(min, math.Min(max, v))
+}
+
+func (resampler *Resampler) resampleChannelData(data []float64) []float64 {
+	data = resampler.filterChannel(data)
+	output := make([]float64, resampler.channelOutputLen(len(data)))
+	written := resampler.resampleChannelInto(output, 1, data, nil)
+	if !resampler.cancelled() {
+		checkLengths("channel", resampler.channelOutputLen(len(data)), len(output), written)
+	}
+	return output
+}
+
+// Resamples a channel into every stride-th sample of dst, writing at most
+// channelOutputLen(len(data)) samples. Returns the amount of samples written.
+// If window is not nil, it is used (and grown) as the interpolation window
+// buffer instead of allocating one.
+func (resampler *Resampler) resampleChannelInto(dst []float64, stride int, data []float64, window *[]float64) int {
+	n := resampler.channelOutputLen(len(data))
+	if limit := (len(dst) + stride - 1) / stride; n > limit {
+		n = limit
+	}
+
+	// The resample step between new samples. Every channel is sampled at the
+	// full rate, so this is the plain ratio of the rates.
+	step := float64(resampler.FromRate) / float64(resampler.ToRate)
+
+	// Resample each position from x0. The position is derived from the index
+	// instead of accumulated, so the amount of iterations always matches the
+	// length computed by channelOutputLen.
+	interp := resampler.interpolatorFor(len(data))
+	before, size := interp.window()
+	if window == nil {
+		window = new([]float64)
+	}
+	if cap(*window) < size {
+		*window = make([]float64, size)
+	}
+	buf := (*window)[:size]
+	for i := 0; i < n; i++ {
+		if i%cancelCheckInterval == 0 && resampler.cancelled() {
+			return i
+		}
+		x := float64(i) * step
 		xi0 := float64(uint64(x))
-		yi0 := uint64(xi0)
-		yo := spline(xi0, data[yi0:yi0+4], x)
-		output[i] = yo
-		i++
+		yi := readWindow(data, int(xi0)-before, buf)
+		dst[i*stride] = resampler.interpolate(interp, yi, x-xi0)
+		if resampler.envelope != nil {
+			dst[i*stride] *= resampler.envelope.at(x)
+		}
+	}
+	if checkInvariants && n == resampler.channelOutputLen(len(data)) && n > 0 {
+		// The positions must cover the whole channel and stop at its end
+		if last := float64(n-1) * step; last >= float64(len(data)) || float64(n)*step < float64(len(data)) {
+			panic(fmt.Sprintf("gomplerate: %d positions with step %v do not end at channel length %d", n, step, len(data)))
+		}
+	}
+	return n
+}
+
+// Panics unless the expected, allocated and produced amount of samples of a
+// resample stage agree. Only checked in builds with the gomplerate_invariants
+// tag, so length regressions are caught where they happen.
+func checkLengths(stage string, expected, allocated, produced int) {
+	if checkInvariants && (allocated != expected || produced != expected) {
+		panic(fmt.Sprintf("gomplerate: %s output of %d samples was allocated %d and produced %d", stage, expected, allocated, produced))
+	}
+}
+
+// Returns the amount of samples resampleChannelData produces for a channel of
+// n samples: one for every position k*step (k >= 0) below n, so the output
+// spans the whole duration of the input.
+func (resampler *Resampler) channelOutputLen(n int) int {
+	from, to := int64(resampler.FromRate), int64(resampler.ToRate)
+	return int((int64(n)*to + from - 1) / from)
+}
+
+// Returns the len(buf) samples of the interpolation window starting at index i
+// of data. Near the ends of data the window is copied into buf, repeating the
+// first or last sample for the positions past the ends.
+func readWindow(data []float64, i int, buf []float64) []float64 {
+	if i >= 0 && i+len(buf) <= len(data) {
+		return data[i : i+len(buf)]
+	}
+	for j := range buf {
+		k := i + j
+		if k < 0 {
+			k = 0
+		}
+		if k >= len(data) {
+			k = len(data) - 1
+		}
+		buf[j] = data[k]
+	}
+	return buf
+}
+
+// Interpolates the value at frac past the integer part of the read position
+// from the window yi of interp, scaled by the gain.
+func (resampler *Resampler) interpolate(interp interpolator, yi []float64, frac float64) float64 {
+	return resampler.outputGain() * resampler.interpolateWindow(interp, yi, frac)
+}
+
+// Returns the factor every output sample is scaled by.
+func (r *Resampler) outputGain() float64 {
+	if r.gain == 0 {
+		return 1
+	}
+	return r.gain
+}
+
+// Returns the factor the output sample read at the input frame position x is
+// scaled by: the gain, times the gain envelope if one is set.
+func (r *Resampler) gainAt(x float64) float64 {
+	if r.envelope == nil {
+		return r.outputGain()
+	}
+	return r.outputGain() * r.envelope.at(x)
+}
+
+// Scales samples by the gain in place.
+func (r *Resampler) applyGain(samples []float64) {
+	if r.gain == 0 {
+		return
+	}
+	for i := range samples {
+		samples[i] *= r.gain
+	}
+}
+
+// Returns the output for when FromRate equals ToRate: data itself at unity
+// gain, and a scaled copy otherwise.
+func (r *Resampler) passThrough(data []float64) []float64 {
+	if r.gain == 0 {
+		return data[:]
+	}
+	out := append([]float64(nil), data...)
+	r.applyGain(out)
+	return out
+}
+
+// Interpolates like interpolate, without applying the gain.
+func (resampler *Resampler) interpolateWindow(interp interpolator, yi []float64, frac float64) float64 {
+	if resampler.PreserveSilence && isSilent(yi) {
+		return 0
 	}
-	return output[:i]
+	if resampler.integerDelays {
+		before, _ := interp.window()
+		return yi[before]
+	}
+	if resampler.overshootGuard > 0 && nearFullScale(yi, resampler.overshootGuard) {
+		before, _ := interp.window()
+		return linearInterpolator{}.interpolate(yi[before:], frac)
+	}
+	return interp.interpolate(yi, frac)
+}
+
+// Reports whether any sample in yi reaches level in magnitude.
+func nearFullScale(yi []float64, level float64) bool {
+	for _, y := range yi {
+		if math.Abs(y) >= level {
+			return true
+		}
+	}
+	return false
+}
+
+func isSilent(yi []float64) bool {
+	for _, y := range yi {
+		if y != 0 {
+			return false
+		}
+	}
+	return true
 }
 
 func spline(xi float64, yi []float64, xo float64) float64 {
@@ -169,35 +953,20 @@ func spline(xi float64, yi []float64, xo float64) float64 {
 	c1, c2 := splineC1(yi), splineC2(yi)
 	m1, m2 := splineM1(c1, c2), splineM2(c1, c2) // m0=m3=0
 
+	// Each segment is the cubic a0 + a1*t + a2*t² + a3*t³ of the offset t
+	// from the start of the segment, evaluated with Horner's method
+	var a0, a1, a2, a3, t float64
 	if xo <= xi+1 {
-		return splineZ0(m1, 1, xi, xi+1, y0, y1, xo)
+		t = xo - xi
+		a0, a1, a2, a3 = y0, y1-y0-m1/6, 0, m1/6
 	} else if xo <= xi+2 {
-		return splineZ1(m1, m2, 1, xi+1, xi+2, y1, y2, xo)
+		t = xo - (xi + 1)
+		a0, a1, a2, a3 = y1, y2-y1-m1/3-m2/6, m1/2, (m2-m1)/6
 	} else {
-		return splineZ2(m2, 1, xi+2, xi+3, y2, y3, xo)
+		t = xo - (xi + 2)
+		a0, a1, a2, a3 = y2, y3-y2-m2/3, m2/2, -m2/6
 	}
-}
-
-func splineZ0(m1, h0, x0, x1, y0, y1, x float64) float64 {
-	v1 := (x - x0) * (x - x0) * (x - x0) * m1 / (6 * h0)
-	v2 := -1.0 * y0 * (x - x1) / h0
-	v3 := (y1 - h0*h0*m1/6) * (x - x0) / h0
-	return v1 + v2 + v3
-}
-
-func splineZ1(m1, m2, h1, x1, x2, y1, y2, x float64) float64 {
-	v0 := -1.0 * (x - x2) * (x - x2) * (x - x2) * m1 / (6 * h1)
-	v1 := (x - x1) * (x - x1) * (x - x1) * m2 / (6 * h1)
-	v2 := -1.0 * (y1 - h1*h1*m1/6) * (x - x2) / h1
-	v3 := (y2 - h1*h1*m2/6) * (x - x1) / h1
-	return v0 + v1 + v2 + v3
-}
-
-func splineZ2(m2, h2, x2, x3, y2, y3, x float64) float64 {
-	v0 := -1.0 * (x - x3) * (x - x3) * (x - x3) * m2 / (6 * h2)
-	v2 := -1.0 * (y2 - h2*h2*m2/6) * (x - x3) / h2
-	v3 := y3 * (x - x2) / h2
-	return v0 + v2 + v3
+	return ((a3*t+a2)*t+a1)*t + a0
 }
 
 func splineM1(c1, c2 float64) float64 {
@@ -0,0 +1,800 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+)
+
+// sine returns frames of an interleaved test tone with a different phase on
+// every channel, so that mixed-up channels show up as a mismatch.
+func sine(frames, channels int, freq, rate float64) []float64 {
+	data := make([]float64, frames*channels)
+	for i := 0; i < frames; i++ {
+		for c := 0; c < channels; c++ {
+			data[i*channels+c] = 0.8 * math.Sin(2*math.Pi*freq*float64(i)/rate+float64(c))
+		}
+	}
+	return data
+}
+
+// constant returns n samples that all hold v.
+func constant(n int, v float64) []float64 {
+	data := make([]float64, n)
+	for i := range data {
+		data[i] = v
+	}
+	return data
+}
+
+func mustResampler(t testing.TB, channels, inputRate, outputRate int, opts ...Option) *Resampler {
+	t.Helper()
+	r, err := NewResampler(channels, inputRate, outputRate, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func assertEqualSamples(t testing.TB, got, want []float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d samples, want %d", len(got), len(want))
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("sample %d: got %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestResampleDual(t *testing.T) {
+	in := sine(1000, 2, 440, 44100)
+	r := mustResampler(t, 2, 44100, 48000)
+	out1, out2 := r.ResampleDual(in, 48000, 16000)
+
+	assertEqualSamples(t, out1, mustResampler(t, 2, 44100, 48000).ResampleFloat64(in))
+	assertEqualSamples(t, out2, mustResampler(t, 2, 44100, 16000).ResampleFloat64(in))
+}
+
+func TestResampleDualInvalidRate(t *testing.T) {
+	r := mustResampler(t, 1, 44100, 48000)
+	out1, out2 := r.ResampleDual(sine(100, 1, 440, 44100), 48000, 0)
+	if out1 == nil {
+		t.Fatal("expected output for the valid rate")
+	}
+	if out2 != nil {
+		t.Fatal("expected nil output for the invalid rate")
+	}
+}
+
+func TestNewResamplerRejectsBadRates(t *testing.T) {
+	nan := math.NaN()
+	tests := []struct {
+		name     string
+		from, to int
+	}{
+		{"zero input", 0, 48000},
+		{"negative output", 44100, -1},
+		{"huge input", math.MaxInt, 48000},
+		{"min int output", 44100, math.MinInt},
+		{"NaN conversion", int(nan), 48000},
+		{"above limit", 44100, MaxSampleRate + 1},
+	}
+	for _, tt := range tests {
+		if _, err := NewResampler(1, tt.from, tt.to); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+	if _, err := NewResampler(1, MaxSampleRate, 1); err != nil {
+		t.Errorf("rates at the limits should be accepted: %v", err)
+	}
+}
+
+func TestPreserveSilence(t *testing.T) {
+	in := sine(3000, 1, 440, 44100)
+	for i := 1000; i < 2000; i++ {
+		in[i] = 0
+	}
+	for _, mode := range []InterpMode{InterpLinear, InterpSpline, InterpSinc} {
+		r := mustResampler(t, 1, 44100, 48000, WithInterpolation(mode))
+		r.PreserveSilence = true
+		out := r.ResampleFloat64(in)
+		// Skip a margin wide enough for the longest kernel on both sides.
+		for i := r.OutputLen(1100); i < r.OutputLen(1900); i++ {
+			if math.Float64bits(out[i]) != 0 {
+				t.Fatalf("mode %d: sample %d inside the silence is %v", mode, i, out[i])
+			}
+		}
+		if out[r.OutputLen(500)] == 0 {
+			t.Fatalf("mode %d: the tone was silenced", mode)
+		}
+	}
+}
+
+func TestOutputHasNoZeroPadding(t *testing.T) {
+	for from := 7000; from < 50000; from += 1337 {
+		for _, to := range []int{1, 3, 8000, 11025, 44100, 48000, 96000} {
+			r := mustResampler(t, 1, from, to)
+			for _, n := range []int{17, 18, 100, 1000, 4097} {
+				out := r.ResampleFloat64(constant(n, 1))
+				if len(out) != r.OutputLen(n) {
+					t.Fatalf("%d Hz to %d Hz, %d samples: got %d samples, want %d", from, to, n, len(out), r.OutputLen(n))
+				}
+				for i, v := range out {
+					if v == 0 {
+						t.Fatalf("%d Hz to %d Hz, %d samples: sample %d is an unwritten zero", from, to, n, i)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestResampleFloat64EEmptyOutput(t *testing.T) {
+	r := mustResampler(t, 1, 48000, 1)
+	for _, in := range [][]float64{nil, make([]float64, 100)} {
+		if _, err := r.ResampleFloat64E(in); !errors.Is(err, ErrEmptyOutput) {
+			t.Errorf("%d samples: got %v, want ErrEmptyOutput", len(in), err)
+		}
+	}
+	in := make([]float64, 48000)
+	out, err := r.ResampleFloat64E(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqualSamples(t, out, r.ResampleFloat64(in))
+}
+
+func TestResampleRing(t *testing.T) {
+	in := sine(1000, 2, 300, 44100)
+	for _, to := range []int{48000, 44100} {
+		r := mustResampler(t, 2, 44100, to, WithGain(0.5))
+		want := r.ResampleFloat64(in)
+		// Cover splits inside a frame, and an empty first or second segment.
+		for _, split := range []int{0, 1, 2, 777, len(in)} {
+			assertEqualSamples(t, r.ResampleRing(in[:split], in[split:]), want)
+		}
+	}
+}
+
+func TestResampleInt16PreservesDC(t *testing.T) {
+	for _, dc := range []int16{math.MinInt16, -32767, -12345, -1, 0, 1, 999, 31999, math.MaxInt16} {
+		in := make([]int16, 4000)
+		for i := range in {
+			in[i] = dc
+		}
+		for _, rates := range [][2]int{{44100, 48000}, {48000, 44100}, {8000, 44100}, {44100, 8001}} {
+			r := mustResampler(t, 2, rates[0], rates[1])
+			for i, v := range r.ResampleInt16(in) {
+				if v != dc {
+					t.Fatalf("%v: a DC of %d became %d at sample %d", rates, dc, v, i)
+				}
+			}
+		}
+	}
+}
+
+// Decimating to 1 Hz extracts the slow trend of a signal. The input is
+// low-passed even without AntiAlias, so faster content averages out instead of
+// folding into the trend as point-sampling would.
+func TestExtremeDecimation(t *testing.T) {
+	const seconds = 60
+	trend := func(t float64) float64 { return 0.5 + 0.3*math.Sin(2*math.Pi*t/seconds) }
+	in := make([]float64, 48000*seconds)
+	noise := make([]float64, len(in))
+	for i := range in {
+		x := float64(i) / 48000
+		noise[i] = 0.2*math.Sin(2*math.Pi*3.7*x) + 0.2*math.Sin(2*math.Pi*1000.25*x)
+		in[i] = trend(x) + noise[i]
+	}
+
+	r := mustResampler(t, 1, 48000, 1)
+	out := r.ResampleFloat64(in)
+	if len(out) != seconds {
+		t.Fatalf("got %d samples, want %d", len(out), seconds)
+	}
+	// The filter delays the trend, but removes the noise: the output is what
+	// the trend alone gives.
+	clean := make([]float64, len(in))
+	for i := range in {
+		clean[i] = in[i] - noise[i]
+	}
+	for i, want := range r.ResampleFloat64(clean) {
+		if math.Abs(out[i]-want) > 0.01 {
+			t.Fatalf("sample %d is %v, want %v as without the noise", i, out[i], want)
+		}
+	}
+
+	var blocks []float64
+	r.ResampleFloat64Blocks(in, 1000, func(block []float64) { blocks = append(blocks, block...) })
+	assertEqualSamples(t, blocks, out)
+
+	// Filtering forwards and backwards keeps the trend in place, apart from the
+	// filters settling at the ends. Point-sampling is up to 0.4 off.
+	zeroPhase := mustResampler(t, 1, 48000, 1, WithZeroPhaseFilters(true))
+	for i, v := range zeroPhase.ResampleFloat64(in) {
+		if want := trend(float64(i)); math.Abs(v-want) > 0.03 {
+			t.Fatalf("zero-phase: sample %d is %v, want %v", i, v, want)
+		}
+	}
+	for _, n := range []int{1, 100} {
+		for i, v := range zeroPhase.ResampleFloat64(in[:n]) {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				t.Fatalf("%d samples: sample %d is %v", n, i, v)
+			}
+		}
+	}
+}
+
+func TestOutputLenIsRounded(t *testing.T) {
+	rates := []int{1, 3, 7, 8000, 11025, 16000, 22050, 44100, 48000, 96000, 192000}
+	for _, from := range rates {
+		for _, to := range rates {
+			for _, channels := range []int{1, 2, 6} {
+				for _, frames := range []int{1, 2, 5, 16, 17, 100, 1023, 4801} {
+					if frames*to/from > 1e5 {
+						continue
+					}
+					want := int(math.Round(float64(frames)*float64(to)/float64(from))) * channels
+					in := make([]float64, frames*channels)
+					for _, low := range []bool{false, true} {
+						r := mustResampler(t, channels, from, to, WithLowMemory(low))
+						if got := r.OutputLen(len(in)); got != want {
+							t.Fatalf("%d Hz to %d Hz, %d frames of %d channels: OutputLen is %d, want %d", from, to, frames, channels, got, want)
+						}
+						if got := len(r.ResampleFloat64(in)); got != want {
+							t.Fatalf("%d Hz to %d Hz, %d frames of %d channels: got %d samples, want %d", from, to, frames, channels, got, want)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// A second of 44.1 kHz stereo is enough for the length products to overflow a
+// 32-bit int, so lengths must be computed the same way on every platform.
+func TestOutputLenOfLongInputs(t *testing.T) {
+	r := mustResampler(t, 2, 44100, 48000)
+	for _, seconds := range []int{1, 5, 3600} {
+		in := 2 * 44100 * seconds
+		if got, want := r.OutputLen(in), 2*48000*seconds; got != want {
+			t.Errorf("%d s: OutputLen is %d, want %d", seconds, got, want)
+		}
+		if got, want := r.channelOutputLen(in/2), 48000*seconds; got != want {
+			t.Errorf("%d s: channelOutputLen is %d, want %d", seconds, got, want)
+		}
+	}
+	if got := len(r.ResampleFloat64(make([]float64, 2*44100*5))); got != 2*48000*5 {
+		t.Errorf("got %d samples for 5 s, want %d", got, 2*48000*5)
+	}
+}
+
+// The cubic spline segments as originally written out, before they were
+// rearranged for Horner's method. m0 and m3 are 0.
+func referenceSpline(xi float64, yi []float64, xo float64) float64 {
+	y0, y1, y2, y3 := yi[0], yi[1], yi[2], yi[3]
+	c1, c2 := splineC1(yi), splineC2(yi)
+	m1, m2 := splineM1(c1, c2), splineM2(c1, c2)
+	x0, x1, x2, x3, x := xi, xi+1, xi+2, xi+3, xo
+
+	if x <= x1 {
+		return (x-x0)*(x-x0)*(x-x0)*m1/6 - y0*(x-x1) + (y1-m1/6)*(x-x0)
+	} else if x <= x2 {
+		return -(x-x2)*(x-x2)*(x-x2)*m1/6 + (x-x1)*(x-x1)*(x-x1)*m2/6 -
+			(y1-m1/6)*(x-x2) + (y2-m2/6)*(x-x1)
+	}
+	return -(x-x3)*(x-x3)*(x-x3)*m2/6 - (y2-m2/6)*(x-x3) + y3*(x-x2)
+}
+
+func TestSplineMatchesReference(t *testing.T) {
+	for i := 0; i < 100000; i++ {
+		yi := []float64{math.Sin(float64(i)), math.Cos(float64(i) * 1.3), math.Sin(float64(i) * 0.7), math.Cos(float64(i) * 2.1)}
+		xi := float64(i % 97)
+		xo := xi + math.Mod(float64(i)*0.0371, 3)
+		if got, want := spline(xi, yi, xo), referenceSpline(xi, yi, xo); math.Abs(got-want) > 1e-12 {
+			t.Fatalf("spline(%v, %v, %v) = %v, want %v", xi, yi, xo, got, want)
+		}
+	}
+}
+
+func BenchmarkSpline(b *testing.B) {
+	yi := []float64{0.1, 0.5, -0.3, 0.2}
+	for _, bm := range []struct {
+		name string
+		fn   func(float64, []float64, float64) float64
+	}{{"Horner", spline}, {"Reference", referenceSpline}} {
+		b.Run(bm.name, func(b *testing.B) {
+			var sum float64
+			for i := 0; i < b.N; i++ {
+				sum += bm.fn(3, yi, 3.4)
+			}
+			_ = sum
+		})
+	}
+}
+
+func TestSetChannelArena(t *testing.T) {
+	in := sine(4000, 4, 300, 44100)
+	r := mustResampler(t, 4, 44100, 48000)
+	want := r.ResampleFloat64(in)
+	allocating := testing.AllocsPerRun(10, func() { r.ResampleFloat64(in) })
+
+	if err := r.SetChannelArena(make([][]float64, 4)); err != nil {
+		t.Fatal(err)
+	}
+	assertEqualSamples(t, r.ResampleFloat64(in), want)
+	if reusing := testing.AllocsPerRun(10, func() { r.ResampleFloat64(in) }); reusing >= allocating {
+		t.Errorf("got %v allocations with an arena, %v without", reusing, allocating)
+	}
+
+	// A per-call copy with another channel count cannot use the arena.
+	stereo := sine(1000, 2, 300, 44100)
+	out := r.ResampleAudio(AudioData{Samples: stereo, SampleRate: 44100, Channels: 2}, 48000)
+	assertEqualSamples(t, out.Samples, mustResampler(t, 2, 44100, 48000).ResampleFloat64(stereo))
+
+	if err := r.SetChannelArena(make([][]float64, 3)); err == nil {
+		t.Error("expected an error for an arena with the wrong channel count")
+	}
+	if err := r.SetChannelArena(nil); err != nil {
+		t.Errorf("clearing the arena failed: %v", err)
+	}
+}
+
+// Rates without a small common factor need no special path: lengths stay exact
+// and positions do not drift over long inputs.
+func TestCoprimeRates(t *testing.T) {
+	r := mustResampler(t, 2, 44099, 48000)
+	for _, frames := range []int{17, 1000, 44099, 441001} {
+		in := sine(frames, 2, 1000, 44099)
+		out := r.ResampleFloat64(in)
+		if want := int(math.Round(float64(frames)*48000/44099)) * 2; len(out) != want {
+			t.Fatalf("%d frames: got %d samples, want %d", frames, len(out), want)
+		}
+		if frames < 44099 {
+			continue
+		}
+		// The last whole second still holds the tone in phase with the input.
+		// It starts at a whole second, where both grids line up exactly.
+		seconds := len(out)/2/48000 - 1
+		amp, phase := tone(out[seconds*48000*2:(seconds+1)*48000*2], 2, 0, 1000, 48000)
+		_, wantPhase := tone(in[seconds*44099*2:(seconds+1)*44099*2], 2, 0, 1000, 44099)
+		if math.Abs(amp-0.8) > 0.01 || math.Abs(math.Remainder(phase-wantPhase, 2*math.Pi)) > 1e-3 {
+			t.Errorf("%d frames: the last second has amplitude %v and phase %v, want 0.8 and %v", frames, amp, phase, wantPhase)
+		}
+	}
+}
+
+// Measures throughput from buffers that fit in cache to ones that do not.
+func BenchmarkResampleFloat64(b *testing.B) {
+	for _, frames := range []int{1 << 10, 1 << 16, 1 << 20} {
+		in := sine(frames, 2, 440, 44100)
+		r := mustResampler(b, 2, 44100, 48000)
+		b.Run(fmt.Sprint(frames), func(b *testing.B) {
+			b.SetBytes(int64(8 * len(in)))
+			for i := 0; i < b.N; i++ {
+				r.ResampleFloat64(in)
+			}
+		})
+	}
+}
+
+func TestResampleFloat64Changed(t *testing.T) {
+	in := sine(100, 2, 440, 44100)
+	same := mustResampler(t, 2, 44100, 44100)
+	out, changed := same.ResampleFloat64Changed(in)
+	if changed || &out[0] != &in[0] {
+		t.Error("equal rates should return the input itself, unchanged")
+	}
+	if _, changed := mustResampler(t, 2, 44100, 48000).ResampleFloat64Changed(in); !changed {
+		t.Error("different rates should report a change")
+	}
+}
+
+// All channels are read at the same positions, so a delay between channels
+// survives resampling.
+func TestInterChannelDelay(t *testing.T) {
+	const freq, delay = 1000.0, 0.3 // Delay of channel 1, in input samples.
+	in := make([]float64, 2*20000)
+	for i := 0; i < 20000; i++ {
+		in[2*i] = math.Sin(2 * math.Pi * freq * float64(i) / 44100)
+		in[2*i+1] = math.Sin(2 * math.Pi * freq * (float64(i) - delay) / 44100)
+	}
+	out := mustResampler(t, 2, 44100, 48000).ResampleFloat64(in)
+
+	seconds := func(data []float64, rate float64) float64 {
+		_, left := tone(data, 2, 0, freq, rate)
+		_, right := tone(data, 2, 1, freq, rate)
+		return math.Remainder(left-right, 2*math.Pi) / (2 * math.Pi * freq)
+	}
+	want := seconds(in, 44100)
+	if got := seconds(out[2000:len(out)-2000], 48000); math.Abs(got-want) > 0.01/44100 {
+		t.Errorf("channel 1 is delayed by %v s, want %v s", got, want)
+	}
+}
+
+// Samples are normalized once: ResampleFloat64 keeps the scale it is given,
+// and ResampleInt16 keeps the amplitude of its input.
+func TestAmplitudeIsPreserved(t *testing.T) {
+	r := mustResampler(t, 1, 44100, 48000)
+	loud := make([]float64, 44100)
+	for i := range loud {
+		loud[i] = 1000 * math.Sin(2*math.Pi*440*float64(i)/44100)
+	}
+	if amp, _ := tone(r.ResampleFloat64(loud), 1, 0, 440, 48000); math.Abs(amp-1000) > 5 {
+		t.Errorf("float amplitude became %v, want 1000", amp)
+	}
+
+	out := r.ResampleInt16(sineInt16(44100, 30000))
+	peak := 0
+	for _, v := range out {
+		if int(v) > peak {
+			peak = int(v)
+		}
+	}
+	if peak < 29900 {
+		t.Errorf("int16 peak became %d, want about 30000", peak)
+	}
+}
+
+func TestChannelsAreResampledIndependently(t *testing.T) {
+	for _, channels := range []int{2, 3, 6} {
+		in := sine(1001, channels, 440, 44100)
+		out := mustResampler(t, channels, 44100, 48000).ResampleFloat64(in)
+		split, err := Deinterleave(in, channels)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mono := mustResampler(t, 1, 44100, 48000)
+		want := make([][]float64, channels)
+		for c := range split {
+			want[c] = mono.ResampleFloat64(split[c])
+		}
+		interleaved, err := Interleave(want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEqualSamples(t, out, interleaved)
+	}
+}
+
+// The step between read positions depends on the rates only, so the channel
+// count changes neither the duration nor the pitch of the output.
+func TestStepIgnoresChannelCount(t *testing.T) {
+	mono := mustResampler(t, 1, 44100, 48000)
+	for _, channels := range []int{2, 6} {
+		r := mustResampler(t, channels, 44100, 48000)
+		if got, want := r.OutputLen(44100*channels)/channels, mono.OutputLen(44100); got != want {
+			t.Errorf("%d channels: got %d frames, want %d", channels, got, want)
+		}
+		if got, want := r.ReadPosition(1000), mono.ReadPosition(1000); got != want {
+			t.Errorf("%d channels: read position %v, want %v", channels, got, want)
+		}
+		out := r.ResampleFloat64(sine(44100, channels, 440, 44100))
+		if amp, _ := tone(out, channels, channels-1, 440, 48000); math.Abs(amp-0.8) > 0.01 {
+			t.Errorf("%d channels: 440 Hz has an amplitude of %v, want 0.8", channels, amp)
+		}
+	}
+}
+
+func TestTailIsResampled(t *testing.T) {
+	ramp := make([]float64, 20)
+	for i := range ramp {
+		ramp[i] = float64(i) / 19
+	}
+	r := mustResampler(t, 1, 44100, 48000)
+	out := r.ResampleFloat64(ramp)
+	if len(out) != 22 {
+		t.Fatalf("got %d samples, want 22", len(out))
+	}
+	// The last frame is read just past the end, so it gets the last sample.
+	if last := out[len(out)-1]; last != 1 {
+		t.Errorf("the last sample is %v, want 1", last)
+	}
+
+	// Inputs shorter than the interpolation window are resampled too.
+	for n := 1; n <= 4; n++ {
+		out := r.ResampleFloat64(constant(n, 0.5))
+		if len(out) != r.OutputLen(n) || len(out) == 0 {
+			t.Fatalf("%d samples: got %d samples, want %d", n, len(out), r.OutputLen(n))
+		}
+		for i, v := range out {
+			if math.Abs(v-0.5) > 1e-12 {
+				t.Fatalf("%d samples: sample %d is %v, want 0.5", n, i, v)
+			}
+		}
+	}
+}
+
+func TestResampleFloat32(t *testing.T) {
+	in := sine(1001, 2, 440, 44100)
+	in32 := make([]float32, len(in))
+	widened := make([]float64, len(in))
+	for i, v := range in {
+		in32[i] = float32(v)
+		widened[i] = float64(in32[i])
+	}
+	for _, to := range []int{48000, 8000} {
+		r := mustResampler(t, 2, 44100, to)
+		want := r.ResampleFloat64(widened)
+		got := r.ResampleFloat32(in32)
+		if len(got) != len(want) {
+			t.Fatalf("got %d samples, want %d", len(got), len(want))
+		}
+		for i := range got {
+			if got[i] != float32(want[i]) {
+				t.Fatalf("%d Hz, sample %d: got %v, want %v", to, i, got[i], float32(want[i]))
+			}
+		}
+	}
+	if out := mustResampler(t, 2, 44100, 44100).ResampleFloat32(in32); &out[0] != &in32[0] {
+		t.Error("equal rates should return the input itself")
+	}
+}
+
+func TestResampleFloat64Into(t *testing.T) {
+	in := sine(1001, 2, 440, 44100)
+	r := mustResampler(t, 2, 44100, 48000)
+	want := r.ResampleFloat64(in)
+
+	dst := make([]float64, len(want)+10)
+	n, err := r.ResampleFloat64Into(dst, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqualSamples(t, dst[:n], want)
+	if allocs := testing.AllocsPerRun(100, func() { r.ResampleFloat64Into(dst, in) }); allocs != 0 {
+		t.Errorf("got %v allocations per call, want 0", allocs)
+	}
+
+	if _, err := r.ResampleFloat64Into(make([]float64, len(want)-1), in); err == nil {
+		t.Error("expected an error for a short destination")
+	}
+}
+
+func TestParallelMatchesSerial(t *testing.T) {
+	in := sine(100000, 8, 440, 44100)
+	serial := mustResampler(t, 8, 44100, 48000)
+	serial.MaxConcurrency = 1
+	serial.AntiAlias = true
+	want := serial.ResampleFloat64(in)
+	for _, concurrency := range []int{0, 3} {
+		r := mustResampler(t, 8, 44100, 48000)
+		r.MaxConcurrency = concurrency
+		r.AntiAlias = true
+		assertEqualSamples(t, r.ResampleFloat64(in), want)
+	}
+}
+
+func BenchmarkResampleFloat64Parallel(b *testing.B) {
+	in := sine(200000, 8, 440, 44100)
+	for _, concurrency := range []int{1, 0} {
+		r := mustResampler(b, 8, 44100, 48000)
+		r.MaxConcurrency = concurrency
+		b.Run(fmt.Sprintf("MaxConcurrency=%d", concurrency), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				r.ResampleFloat64(in)
+			}
+		})
+	}
+}
+
+func TestResampleFloat64EPartialFrame(t *testing.T) {
+	r := mustResampler(t, 2, 44100, 48000)
+	if _, err := r.ResampleFloat64E(make([]float64, 101)); err == nil || errors.Is(err, ErrEmptyOutput) {
+		t.Errorf("got %v, want an error about the partial frame", err)
+	}
+	// ResampleFloat64 counts the partial frame as a frame instead.
+	if out := r.ResampleFloat64(make([]float64, 101)); len(out) != r.OutputLen(101) {
+		t.Errorf("got %d samples, want %d", len(out), r.OutputLen(101))
+	}
+}
+
+func TestReconfigure(t *testing.T) {
+	in := sine(4410, 1, 1000, 44100)
+	bands := []Band{{Type: BandPeaking, Freq: 1000, GainDB: 6, Q: 1}}
+	r := mustResampler(t, 2, 44100, 48000, WithEQ(bands), WithNoiseGate(-40, 1, 50))
+	s := r.Stream()
+	s.Process(sine(1000, 2, 440, 44100))
+
+	if err := r.Reconfigure(1, 96000, 22050); err != nil {
+		t.Fatal(err)
+	}
+	// The EQ and gate are redesigned for 96 kHz, as if the resampler was new.
+	fresh := mustResampler(t, 1, 96000, 22050, WithEQ(bands), WithNoiseGate(-40, 1, 50))
+	assertEqualSamples(t, r.ResampleFloat64(in), fresh.ResampleFloat64(in))
+
+	// The stream drops what it buffered before.
+	out, _, _ := s.Process(in)
+	out = append(out, s.Flush()...)
+	assertEqualSamples(t, out, fresh.ResampleFloat64(in))
+
+	// At 1500 Hz, the EQ band is above the Nyquist frequency.
+	for _, args := range [][3]int{{0, 44100, 48000}, {1, -1, 48000}, {1, 44100, 0}, {1, 1500, 48000}} {
+		if err := r.Reconfigure(args[0], args[1], args[2]); err == nil {
+			t.Errorf("Reconfigure%v: expected an error", args)
+		}
+	}
+	// A failed Reconfigure leaves the resampler as it was.
+	if r.Channels != 1 || r.FromRate != 96000 || r.ToRate != 22050 {
+		t.Errorf("failed Reconfigure changed the resampler to %d channels, %d Hz to %d Hz", r.Channels, r.FromRate, r.ToRate)
+	}
+}
+
+func TestReset(t *testing.T) {
+	in := sine(2000, 1, 440, 44100)
+	r := mustResampler(t, 1, 44100, 48000)
+	s := r.Stream()
+	s.Process(in[:777])
+	r.Reset()
+	out, _, _ := s.Process(in)
+	out = append(out, s.Flush()...)
+	assertEqualSamples(t, out, r.ResampleFloat64(in))
+}
+
+func TestOutputLen(t *testing.T) {
+	r := mustResampler(t, 2, 44100, 48000)
+	tests := []struct{ in, want int }{
+		{-2, 0},
+		{0, 0},
+		{1, 2}, // A partial frame counts as a frame.
+		{2, 2},
+		{3, 4},
+		{88200, 96000},
+	}
+	for _, tt := range tests {
+		if got := r.OutputLen(tt.in); got != tt.want {
+			t.Errorf("OutputLen(%d) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+	if got := mustResampler(t, 2, 48000, 48000).OutputLen(3); got != 3 {
+		t.Errorf("at equal rates, OutputLen(3) = %d, want 3", got)
+	}
+
+	// Every one-shot method produces exactly OutputLen samples.
+	in := sine(1001, 2, 440, 44100)
+	want := r.OutputLen(len(in))
+	if got := len(r.ResampleFloat32(make([]float32, len(in)))); got != want {
+		t.Errorf("ResampleFloat32: got %d samples, want %d", got, want)
+	}
+	if got := len(r.ResampleInt16(make([]int16, len(in)))); got != want {
+		t.Errorf("ResampleInt16: got %d samples, want %d", got, want)
+	}
+}
+
+func TestResampleToFloat32(t *testing.T) {
+	in := sine(1001, 3, 440, 44100)
+	for _, rates := range [][2]int{{44100, 48000}, {44100, 44100}} {
+		r := mustResampler(t, 3, rates[0], rates[1])
+		want := r.ResampleFloat64(in)
+		got := r.ResampleToFloat32(in)
+		if len(got) != len(want) {
+			t.Fatalf("%d Hz to %d Hz: got %d samples, want %d", rates[0], rates[1], len(got), len(want))
+		}
+		for i := range got {
+			if got[i] != float32(want[i]) {
+				t.Fatalf("%d Hz to %d Hz, sample %d: got %v, want %v", rates[0], rates[1], i, got[i], float32(want[i]))
+			}
+		}
+	}
+	if got := mustResampler(t, 1, 44100, 48000).ResampleToFloat32(nil); got != nil {
+		t.Errorf("got %d samples for an empty input", len(got))
+	}
+}
+
+// A context that is cancelled once its Err method was called a given amount of
+// times, so cancellation can land in the middle of resampling.
+type countdownContext struct {
+	context.Context
+	calls, limit int
+}
+
+func (c *countdownContext) Err() error {
+	if c.calls++; c.calls > c.limit {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestResampleFloat64Context(t *testing.T) {
+	in := sine(44100, 2, 440, 44100)
+	r := mustResampler(t, 2, 44100, 48000, WithInterpolation(InterpSinc))
+	out, err := r.ResampleFloat64Context(context.Background(), in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqualSamples(t, out, r.ResampleFloat64(in))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if out, err := r.ResampleFloat64Context(ctx, in); out != nil || !errors.Is(err, context.Canceled) {
+		t.Errorf("got %d samples and %v for a cancelled context", len(out), err)
+	}
+
+	full := &countdownContext{Context: context.Background(), limit: math.MaxInt32}
+	if _, err := r.ResampleFloat64Context(full, in); err != nil {
+		t.Fatal(err)
+	}
+	early := &countdownContext{Context: context.Background(), limit: 3}
+	if out, err := r.ResampleFloat64Context(early, in); out != nil || !errors.Is(err, context.Canceled) {
+		t.Errorf("got %d samples and %v when cancelled while resampling", len(out), err)
+	}
+	if early.calls >= full.calls {
+		t.Errorf("resampling went on after the cancellation (%d checks, %d without cancelling)", early.calls, full.calls)
+	}
+}
+
+func TestMonoFastPath(t *testing.T) {
+	in := sine(4410, 1, 440, 44100)
+	orig := append([]float64(nil), in...)
+	for _, mode := range []InterpMode{InterpSpline, InterpSinc} {
+		r := mustResampler(t, 1, 44100, 22050, WithInterpolation(mode))
+		r.AntiAlias = true
+		assertEqualSamples(t, r.ResampleFloat64(in), r.resampleSplit(r.splitChannels(in), len(in)))
+		// The filters must not run over the caller's buffer.
+		assertEqualSamples(t, in, orig)
+	}
+
+	r := mustResampler(t, 1, 44100, 48000)
+	fast := testing.AllocsPerRun(10, func() { r.ResampleFloat64(in) })
+	split := testing.AllocsPerRun(10, func() { r.resampleSplit(r.splitChannels(in), len(in)) })
+	if fast >= split {
+		t.Errorf("mono input makes %v allocations, as many as splitting it (%v)", fast, split)
+	}
+}
+
+func TestInt16Extremes(t *testing.T) {
+	r := mustResampler(t, 1, 44100, 48000)
+	if got := r.fromInt16(math.MinInt16); got != -1 {
+		t.Errorf("-32768 maps to %v, want -1", got)
+	}
+	for _, tt := range []struct {
+		in   float64
+		want int16
+	}{{-1, math.MinInt16}, {1, math.MaxInt16}, {-2, math.MinInt16}, {2, math.MaxInt16}} {
+		if got := r.toInt16(tt.in); got != tt.want {
+			t.Errorf("toInt16(%v) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+
+	// Held extremes come back intact.
+	in := make([]int16, 200)
+	for i := range in {
+		in[i] = math.MinInt16
+		if i >= 100 {
+			in[i] = math.MaxInt16
+		}
+	}
+	out := r.ResampleInt16(in)
+	if out[10] != math.MinInt16 || out[len(out)-10] != math.MaxInt16 {
+		t.Errorf("got %d and %d, want %d and %d", out[10], out[len(out)-10], math.MinInt16, math.MaxInt16)
+	}
+}
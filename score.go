@@ -0,0 +1,67 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import "math"
+
+// Resamples a float64 audio buffer and rates how faithful the result is expected
+// to be, from 0 (unusable) to 1 (no known problems). The score is the product of:
+//
+//   - Aliasing: ToRate/FromRate when downsampling without the AntiAlias
+//     filter, because nothing removes the content above the new Nyquist
+//     frequency before it folds back. 1 otherwise.
+//   - Clipping: 1 minus ten times the fraction of output samples outside [-1, 1].
+//   - Coverage: the fraction of the input duration the output spans, which is
+//     only below 1 by the rounding of the output length.
+//
+// Returns the resampled buffer and the score.
+func (r *Resampler) ResampleFloat64Scored(data []float64) (out []float64, score float64) {
+	out = r.ResampleFloat64(data)
+	if len(out) == 0 {
+		return out, 0
+	}
+
+	score = 1
+	if r.ToRate < r.FromRate && !r.antiAliased() {
+		score *= float64(r.ToRate) / float64(r.FromRate)
+	}
+
+	clipped := 0
+	for _, v := range out {
+		if v > 1 || v < -1 {
+			clipped++
+		}
+	}
+	score *= math.Max(0, 1-10*float64(clipped)/float64(len(out)))
+
+	if r.FromRate != r.ToRate {
+		frames := len(data) / r.Channels
+		covered := float64(len(out)/r.Channels) * float64(r.FromRate) / float64(r.ToRate)
+		score *= math.Min(1, covered/float64(frames))
+	}
+
+	return out, score
+}
@@ -0,0 +1,59 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import (
+	"math"
+	"testing"
+)
+
+func TestResampleFloat64Scored(t *testing.T) {
+	in := sine(4410, 1, 300, 44100)
+
+	up := mustResampler(t, 1, 44100, 96000)
+	out, score := up.ResampleFloat64Scored(in)
+	assertEqualSamples(t, out, up.ResampleFloat64(in))
+	if score != 1 {
+		t.Errorf("clean upsampling scored %v, want 1", score)
+	}
+
+	down := mustResampler(t, 1, 48000, 8000)
+	if _, score := down.ResampleFloat64Scored(in); math.Abs(score-1.0/6) > 1e-12 {
+		t.Errorf("unfiltered downsampling by 6 scored %v, want 1/6", score)
+	}
+	down.AntiAlias = true
+	if _, score := down.ResampleFloat64Scored(in); score < 0.99 {
+		t.Errorf("filtered downsampling scored %v, want about 1", score)
+	}
+
+	loud := make([]float64, len(in))
+	for i, v := range in {
+		loud[i] = 2 * v
+	}
+	if _, score := up.ResampleFloat64Scored(loud); score != 0 {
+		t.Errorf("heavily clipped output scored %v, want 0", score)
+	}
+}
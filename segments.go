@@ -0,0 +1,56 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import "math"
+
+// Resamples a float64 audio buffer and splits the result into consecutive
+// segments of segmentSeconds at the new rate, each holding
+// segmentSeconds*ToRate frames (rounded, at least one) of interleaved samples.
+// The last segment may be shorter. The segments share the memory of a single
+// output buffer. Returns nil if segmentSeconds is not positive.
+func (r *Resampler) ResampleSegmented(data []float64, segmentSeconds float64) [][]float64 {
+	if !(segmentSeconds > 0) {
+		return nil
+	}
+	out := r.ResampleFloat64(data)
+	frames := math.Max(1, math.Round(segmentSeconds*float64(r.ToRate)))
+	size := len(out)
+	if frames*float64(r.Channels) < float64(len(out)) {
+		size = int(frames) * r.Channels
+	}
+
+	var segments [][]float64
+	for len(out) > 0 {
+		n := size
+		if n > len(out) {
+			n = len(out)
+		}
+		segments = append(segments, out[:n:n])
+		out = out[n:]
+	}
+	return segments
+}
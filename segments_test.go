@@ -0,0 +1,60 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import (
+	"math"
+	"testing"
+)
+
+func TestResampleSegmented(t *testing.T) {
+	in := sine(44100*5/2, 2, 440, 44100)
+	r := mustResampler(t, 2, 44100, 48000)
+	segments := r.ResampleSegmented(in, 1)
+
+	// 2.5 seconds make two whole 1 second segments and one of half a second.
+	wantLens := []int{2 * 48000, 2 * 48000, 2 * 24000}
+	if len(segments) != len(wantLens) {
+		t.Fatalf("got %d segments, want %d", len(segments), len(wantLens))
+	}
+	var joined []float64
+	for i, segment := range segments {
+		if len(segment) != wantLens[i] {
+			t.Errorf("segment %d holds %d samples, want %d", i, len(segment), wantLens[i])
+		}
+		joined = append(joined, segment...)
+	}
+	assertEqualSamples(t, joined, r.ResampleFloat64(in))
+
+	if got := r.ResampleSegmented(in, 1e300); len(got) != 1 {
+		t.Errorf("got %d segments for a huge duration, want 1", len(got))
+	}
+	for _, seconds := range []float64{0, -1, math.NaN()} {
+		if r.ResampleSegmented(in, seconds) != nil {
+			t.Errorf("expected nil for %v seconds", seconds)
+		}
+	}
+}
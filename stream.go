@@ -0,0 +1,348 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Resamples a continuous signal that arrives in chunks. It keeps the input
+// frames the spline still needs and the read position between calls, so the
+// output of consecutive calls joins without seams. Create one with
+// Resampler.Stream. A StreamResampler is not safe for concurrent use.
+type StreamResampler struct {
+	// Treats every chunk passed to Process as the segment right before the
+	// previous one, for streaming a signal backwards. The output is the result
+	// of ResampleFloat64 for the whole signal with its frames reversed, so it
+	// plays backwards too, and is returned chunk by chunk as it arrives. The
+	// sample grid starts at the last frame of the signal, the first to arrive,
+	// and the interpolation windows reach toward its start, so unless FromRate
+	// equals ToRate the output differs slightly from ResampleFloat64 of the
+	// forward signal, reversed. Set it before the first call to Process.
+	Reverse bool
+
+	resampler  *Resampler
+	history    [][]float64 // Buffered input frames per channel.
+	offset     int         // Index of the first buffered frame in the stream.
+	next       int         // Index of the next output frame in the stream.
+	filters    [][]sampleFilter
+	outputs    [][]sampleFilter // Filters of every output channel.
+	pending    []float64        // Partial frame left over by Write.
+	config     [3]int           // FromRate, ToRate and Channels the stream started with.
+	generation int              // The resampler generation the stream started in.
+}
+
+// Creates a StreamResampler using the rates and channels of the resampler.
+func (r *Resampler) Stream() *StreamResampler {
+	s := &StreamResampler{resampler: r}
+	s.reset()
+	return s
+}
+
+// Resamples the next chunk of an interleaved stream like Process, but chunks
+// do not have to hold whole frames: a partial frame is kept and joined with the
+// next chunk. A partial frame still pending when Flush is called is dropped.
+// Returns an error, without consuming anything, if the rates or the channel
+// count of the resampler were changed since the stream started, or
+// ErrOutputTooLarge if the output of the chunk would exceed the limit set with
+// WithMaxOutputSamples.
+func (s *StreamResampler) Write(in []float64) ([]float64, error) {
+	r := s.resampler
+	if config := [3]int{r.FromRate, r.ToRate, r.Channels}; config != s.config && s.generation == r.generation {
+		return nil, fmt.Errorf("resampler changed mid-stream (%d Hz to %d Hz with %d channels became %d Hz to %d Hz with %d channels)",
+			s.config[0], s.config[1], s.config[2], config[0], config[1], config[2])
+	}
+	s.resetIfStale()
+
+	chunk := make([]float64, 0, len(s.pending)+len(in))
+	if s.Reverse {
+		chunk = append(append(chunk, in...), s.pending...)
+	} else {
+		chunk = append(append(chunk, s.pending...), in...)
+	}
+	if frames := len(chunk) / r.Channels; s.framesWithinLimit(frames) < frames {
+		return nil, ErrOutputTooLarge
+	}
+	out, consumed, _ := s.Process(chunk)
+	if s.Reverse {
+		s.pending = append(s.pending[:0], chunk[:len(chunk)-consumed]...)
+	} else {
+		s.pending = append(s.pending[:0], chunk[consumed:]...)
+	}
+	return out, nil
+}
+
+// Resamples the next chunk of an interleaved stream. Only whole frames are
+// consumed: if len(in) is not a multiple of the channel count, the trailing
+// partial frame is left unconsumed and should be passed again with the next
+// chunk. In Reverse mode the leading partial frame, in[:len(in)-consumed], is
+// left unconsumed instead and belongs at the end of the next chunk. Returns the
+// output that could be produced so far, the amount of input samples consumed and
+// the amount of output samples produced. With WithMaxOutputSamples, only as
+// many frames are consumed as keep the output within the limit, and the rest
+// should be passed again too. If the rates or the channel count of the
+// resampler were changed since the stream started, its buffered state is
+// dropped and a new stream starts with the current configuration.
+func (s *StreamResampler) Process(in []float64) (out []float64, consumed int, produced int) {
+	r := s.resampler
+	s.resetIfStale()
+	frames := s.framesWithinLimit(len(in) / r.Channels)
+	consumed = frames * r.Channels
+
+	if s.Reverse {
+		in = s.reverseFrames(in[len(in)-consumed:])
+	}
+	if r.FromRate == r.ToRate {
+		out = make([]float64, consumed)
+		copy(out, in)
+		r.applyGain(out)
+		return out, consumed, consumed
+	}
+
+	for i := 0; i < consumed; i++ {
+		c := i % r.Channels
+		v := in[i]
+		for _, f := range s.filters[c] {
+			v = f.process(v)
+		}
+		s.history[c] = append(s.history[c], v)
+	}
+
+	out = s.emit(out, s.available(s.offset+len(s.history[0])), r.interpolator())
+	s.filterOutput(out)
+
+	return out, consumed, len(out)
+}
+
+// Ends the stream and returns the output frames that were held back because
+// they depend on where the stream ends. After Flush, everything streamed equals
+// what ResampleFloat64 returns for the whole signal at once, in length and in
+// content, with three exceptions: WithAnchorEndpoints is not applied to
+// streams, WithZeroPhaseFilters cannot run the filters backwards over a stream,
+// and a trailing partial frame, which ResampleFloat64 counts as a frame, is
+// never resampled by a stream. The StreamResampler can then be used for a new
+// stream.
+func (s *StreamResampler) Flush() []float64 {
+	r := s.resampler
+	s.resetIfStale()
+	defer s.reset()
+	if r.FromRate == r.ToRate {
+		return nil
+	}
+
+	// Now that the end is known, the remaining frames interpolate up to it, with
+	// the kernel fitted to the length like ResampleFloat64 does; streams
+	// shorter than the kernel have not emitted anything before
+	total := s.offset + len(s.history[0])
+	out := s.emit(nil, r.OutputLen(total*r.Channels)/r.Channels, r.interpolatorFor(total))
+	s.filterOutput(out)
+	return out
+}
+
+// Interpolates the output frames up to (not including) the stream frame index
+// end with interp, appending them to out, and drops the input frames no longer
+// needed. Windows reaching past the buffered input repeat its last frame.
+func (s *StreamResampler) emit(out []float64, end int, interp interpolator) []float64 {
+	r := s.resampler
+	step := float64(r.FromRate) / float64(r.ToRate)
+	before, size := interp.window()
+	window := make([]float64, size)
+	for ; s.next < end; s.next++ {
+		x := float64(s.next) * step
+		xi := int(x)
+		for c := 0; c < r.Channels; c++ {
+			yi := readWindow(s.history[c], xi-before-s.offset, window)
+			out = append(out, r.interpolate(interp, yi, x-float64(xi)))
+		}
+	}
+	s.discard(int(float64(s.next)*step) - before)
+	return out
+}
+
+// Ends the current stream and switches its resampler to a new channel count and
+// rates, validated like Resampler.Reconfigure, so the next chunk starts a new
+// stream in the new configuration. Returns the tail of the old stream, as Flush
+// would, since it belongs before any output of the new configuration. Other
+// streams of the same resampler are reset. Returns an error, leaving the stream
+// and the resampler unchanged, if the new configuration is invalid.
+func (s *StreamResampler) Reconfigure(channels, inputRate, outputRate int) (tail []float64, err error) {
+	r := s.resampler
+	if err := r.validateReconfigure(channels, inputRate, outputRate); err != nil {
+		return nil, err
+	}
+	tail = s.Flush()
+	if err := r.Reconfigure(channels, inputRate, outputRate); err != nil {
+		return tail, err
+	}
+	s.reset()
+	return tail, nil
+}
+
+// Returns how many output frames can be produced once n input frames of the
+// stream are known: those whose whole spline window is known, up to the
+// output length of n frames. These are the frames ResampleFloat64 would
+// interpolate for any signal at least n frames long.
+func (s *StreamResampler) available(n int) int {
+	r := s.resampler
+	before, size := r.interpolator().window()
+	after := size - 1 - before
+	if n <= after {
+		return 0
+	}
+	available := r.channelOutputLen(n - after)
+	if frames := r.OutputLen(n*r.Channels) / r.Channels; frames < available {
+		available = frames
+	}
+	return available
+}
+
+// Returns how many of the next frames input frames the stream can take without
+// producing more output frames in one call than the limit set with
+// WithMaxOutputSamples allows.
+func (s *StreamResampler) framesWithinLimit(frames int) int {
+	r := s.resampler
+	if r.maxOutput == 0 {
+		return frames
+	}
+	limit := r.maxOutput / r.Channels
+	if r.FromRate == r.ToRate {
+		if frames > limit {
+			return limit
+		}
+		return frames
+	}
+	// The output available grows with the input, so search for the first
+	// frame count that goes over
+	known := s.offset + len(s.history[0])
+	return sort.Search(frames+1, func(n int) bool {
+		return s.available(known+n)-s.next > limit
+	}) - 1
+}
+
+// Starts a new stream if the resampler was reset or reconfigured (including by
+// changing its fields directly) since the current one started.
+func (s *StreamResampler) resetIfStale() {
+	r := s.resampler
+	if s.generation != r.generation || s.config != [3]int{r.FromRate, r.ToRate, r.Channels} {
+		s.reset()
+	}
+}
+
+// Drops all buffered state, starting a new stream with the current rates and
+// channel count of the resampler.
+func (s *StreamResampler) reset() {
+	r := s.resampler
+	s.config = [3]int{r.FromRate, r.ToRate, r.Channels}
+	s.generation = r.generation
+	if len(s.history) != r.Channels {
+		s.history = make([][]float64, r.Channels)
+	}
+	for c := range s.history {
+		s.history[c] = s.history[c][:0]
+	}
+	s.offset = 0
+	s.next = 0
+	s.pending = s.pending[:0]
+	s.filters = make([][]sampleFilter, len(s.history))
+	for c := range s.filters {
+		s.filters[c] = s.resampler.inputFilters()
+	}
+	s.outputs = make([][]sampleFilter, len(s.history))
+	for c := range s.outputs {
+		s.outputs[c] = s.resampler.outputFilters()
+	}
+}
+
+// Applies the output filters to interleaved output frames in place.
+func (s *StreamResampler) filterOutput(out []float64) {
+	for i := range out {
+		for _, f := range s.outputs[i%len(s.outputs)] {
+			out[i] = f.process(out[i])
+		}
+	}
+}
+
+// Returns a copy of the interleaved frames of in in reverse order.
+func (s *StreamResampler) reverseFrames(in []float64) []float64 {
+	channels := s.resampler.Channels
+	frames := len(in) / channels
+	out := make([]float64, len(in))
+	for f := 0; f < frames; f++ {
+		copy(out[(frames-1-f)*channels:(frames-f)*channels], in[f*channels:(f+1)*channels])
+	}
+	return out
+}
+
+// Drops buffered frames that come before the stream frame index keep.
+func (s *StreamResampler) discard(keep int) {
+	n := keep - s.offset
+	if n <= 0 {
+		return
+	}
+	if n > len(s.history[0]) {
+		n = len(s.history[0])
+	}
+	for c := range s.history {
+		remaining := copy(s.history[c], s.history[c][n:])
+		s.history[c] = s.history[c][:remaining]
+	}
+	s.offset += n
+}
+
+// Resamples a float64 audio buffer block by block, calling emit with every
+// output block as soon as it is ready instead of returning the whole result at
+// once. Each block is produced from the next blockFrames input frames, so the
+// first output is available after reading only a small prefix of data. The
+// blocks joined together equal the result of ResampleFloat64, with the
+// exceptions listed at StreamResampler.Flush (anchored endpoints, zero-phase
+// filters and a trailing partial frame). emit must not keep the block after
+// returning. Nothing is emitted if the output would exceed the
+// limit set with WithMaxOutputSamples.
+func (r *Resampler) ResampleFloat64Blocks(data []float64, blockFrames int, emit func(block []float64)) {
+	if r.outputTooLarge(len(data)) {
+		return
+	}
+	if blockFrames < 1 {
+		blockFrames = 1
+	}
+	s := r.Stream()
+	blockLen := blockFrames * r.Channels
+	for len(data) >= r.Channels {
+		n := blockLen
+		if n > len(data) {
+			n = len(data)
+		}
+		out, consumed, _ := s.Process(data[:n])
+		if len(out) > 0 {
+			emit(out)
+		}
+		data = data[consumed:]
+	}
+	if out := s.Flush(); len(out) > 0 {
+		emit(out)
+	}
+}
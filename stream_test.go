@@ -0,0 +1,245 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import (
+	"math"
+	"testing"
+)
+
+func TestStreamProcessCounts(t *testing.T) {
+	in := sine(5000, 2, 440, 44100)
+	r := mustResampler(t, 2, 44100, 48000)
+	s := r.Stream()
+
+	var got []float64
+	sizes := []int{7, 1, 100, 333, 2, 1001, 64}
+	for i, pos := 0, 0; pos < len(in); i++ {
+		n := sizes[i%len(sizes)]
+		if pos+n > len(in) {
+			n = len(in) - pos
+		}
+		out, consumed, produced := s.Process(in[pos : pos+n])
+		if consumed != n/2*2 {
+			t.Fatalf("chunk of %d samples: consumed %d, want %d", n, consumed, n/2*2)
+		}
+		if produced != len(out) {
+			t.Fatalf("produced %d, but returned %d samples", produced, len(out))
+		}
+		if produced%2 != 0 {
+			t.Fatalf("produced a partial frame (%d samples)", produced)
+		}
+		pos += consumed
+		got = append(got, out...)
+	}
+	got = append(got, s.Flush()...)
+	assertEqualSamples(t, got, r.ResampleFloat64(in))
+}
+
+func reversedFrames(data []float64, channels int) []float64 {
+	out := make([]float64, 0, len(data))
+	for f := len(data)/channels - 1; f >= 0; f-- {
+		out = append(out, data[f*channels:(f+1)*channels]...)
+	}
+	return out
+}
+
+func TestStreamReverse(t *testing.T) {
+	in := sine(3001, 2, 440, 44100)
+	for _, rates := range [][2]int{{44100, 48000}, {48000, 44100}, {44100, 44100}} {
+		r := mustResampler(t, 2, rates[0], rates[1])
+		s := r.Stream()
+		s.Reverse = true
+
+		var got []float64
+		for end := len(in); end > 0; end -= 250 {
+			start := end - 250
+			if start < 0 {
+				start = 0
+			}
+			out, consumed, _ := s.Process(in[start:end])
+			if consumed != end-start {
+				t.Fatalf("%v: consumed %d of %d samples", rates, consumed, end-start)
+			}
+			if end < len(in)-500 && len(out) == 0 {
+				t.Fatalf("%v: no output for the chunk ending at sample %d", rates, end)
+			}
+			got = append(got, out...)
+		}
+		got = append(got, s.Flush()...)
+		// The output is the time-reversed signal, resampled.
+		assertEqualSamples(t, got, r.ResampleFloat64(reversedFrames(in, 2)))
+	}
+
+	// With the last frame on the forward grid and a symmetric kernel, both
+	// grids read the same windows, so the output is the forward result
+	// reversed.
+	in = sine(4411, 2, 440, 44100)
+	r := mustResampler(t, 2, 44100, 48000, WithInterpolation(InterpLinear))
+	s := r.Stream()
+	s.Reverse = true
+	half := len(in) / 4 * 2
+	got, _, _ := s.Process(in[half:])
+	rest, _, _ := s.Process(in[:half])
+	got = append(append(got, rest...), s.Flush()...)
+	want := reversedFrames(r.ResampleFloat64(in), 2)
+	if len(got) != len(want) {
+		t.Fatalf("got %d samples, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 1e-9 {
+			t.Fatalf("sample %d: got %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestResampleFloat64BlocksLatency(t *testing.T) {
+	in := sine(44100, 2, 300, 44100)
+	r := mustResampler(t, 2, 44100, 48000)
+
+	var blocks [][]float64
+	r.ResampleFloat64Blocks(in, 64, func(block []float64) {
+		blocks = append(blocks, append([]float64(nil), block...))
+	})
+	if len(blocks) < 2 {
+		t.Fatalf("got %d blocks, want one per input block", len(blocks))
+	}
+	// The first block only depends on the first 64 input frames.
+	if n := len(blocks[0]) / 2; n == 0 || n > 64*48000/44100+1 {
+		t.Fatalf("first block holds %d frames", n)
+	}
+	var joined []float64
+	for _, b := range blocks {
+		joined = append(joined, b...)
+	}
+	assertEqualSamples(t, joined, r.ResampleFloat64(in))
+}
+
+// Streams have no warmup: the first Process call already returns the start of
+// the signal, matching the one-shot result sample for sample.
+func TestStreamFirstProcessHasNoWarmup(t *testing.T) {
+	in := sine(2000, 2, 440, 44100)
+	r := mustResampler(t, 2, 44100, 48000)
+	out, _, _ := r.Stream().Process(in[:200])
+	if len(out) < 2*100 {
+		t.Fatalf("the first 100 frames produced only %d samples", len(out))
+	}
+	want := r.ResampleFloat64(in)
+	assertEqualSamples(t, out, want[:len(out)])
+	if out[2] == 0 || out[3] == 0 {
+		t.Fatal("the first frames are silent")
+	}
+}
+
+func TestResampleFloat64BlocksMatchesOneShot(t *testing.T) {
+	for _, rates := range [][2]int{{44100, 48000}, {48000, 44100}, {8000, 44100}, {48000, 1}, {48000, 7}, {44100, 8001}} {
+		for _, n := range []int{0, 1, 3, 16, 17, 20, 100, 1001, 45500} {
+			for _, channels := range []int{1, 2, 3} {
+				r := mustResampler(t, channels, rates[0], rates[1])
+				in := sine(n, channels, 300, 44100)
+				for _, blockFrames := range []int{1, 37} {
+					var got []float64
+					r.ResampleFloat64Blocks(in, blockFrames, func(block []float64) { got = append(got, block...) })
+					want := r.ResampleFloat64(in)
+					if len(got) != len(want) {
+						t.Fatalf("%v, %d frames of %d channels in blocks of %d: got %d samples, want %d", rates, n, channels, blockFrames, len(got), len(want))
+					}
+					assertEqualSamples(t, got, want)
+				}
+			}
+		}
+	}
+}
+
+// Chunks passed to Write may split frames anywhere.
+func TestStreamWriteMatchesOneShot(t *testing.T) {
+	in := sine(10007, 2, 440, 44100)
+	r := mustResampler(t, 2, 44100, 48000)
+	s := r.Stream()
+
+	var got []float64
+	rest := in
+	for _, n := range []int{1, 3, 2000, 7, 5, 1001, 333, 4000, 1, 100000} {
+		if n > len(rest) {
+			n = len(rest)
+		}
+		out, err := s.Write(rest[:n])
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, out...)
+		rest = rest[n:]
+	}
+	got = append(got, s.Flush()...)
+	assertEqualSamples(t, got, r.ResampleFloat64(in))
+}
+
+func TestStreamConfigChange(t *testing.T) {
+	in := sine(1000, 2, 440, 44100)
+	r := mustResampler(t, 2, 44100, 48000)
+	s := r.Stream()
+	if _, err := s.Write(in[:500]); err != nil {
+		t.Fatal(err)
+	}
+
+	r.ToRate = 22050
+	if _, err := s.Write(in[500:]); err == nil {
+		t.Fatal("expected an error from Write after the rates changed")
+	}
+	// Process starts over with the new configuration.
+	out, _, _ := s.Process(in)
+	out = append(out, s.Flush()...)
+	assertEqualSamples(t, out, mustResampler(t, 2, 44100, 22050).ResampleFloat64(in))
+}
+
+func TestStreamReconfigure(t *testing.T) {
+	stereo := sine(1000, 2, 440, 44100)
+	mono := sine(800, 1, 440, 48000)
+	r := mustResampler(t, 2, 44100, 48000)
+	want := r.ResampleFloat64(stereo)
+	s := r.Stream()
+
+	out, _, _ := s.Process(stereo)
+	tail, err := s.Reconfigure(1, 48000, 16000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The tail completes the old stream.
+	assertEqualSamples(t, append(out, tail...), want)
+
+	out, _, _ = s.Process(mono)
+	out = append(out, s.Flush()...)
+	assertEqualSamples(t, out, mustResampler(t, 1, 48000, 16000).ResampleFloat64(mono))
+
+	// A failed Reconfigure leaves the stream as it was.
+	out, _, _ = s.Process(mono[:400])
+	if tail, err := s.Reconfigure(0, 48000, 16000); err == nil || tail != nil {
+		t.Fatalf("got %d samples and %v, want an error and no tail", len(tail), err)
+	}
+	rest, _, _ := s.Process(mono[400:])
+	out = append(append(out, rest...), s.Flush()...)
+	assertEqualSamples(t, out, mustResampler(t, 1, 48000, 16000).ResampleFloat64(mono))
+}
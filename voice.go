@@ -0,0 +1,61 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import "math"
+
+// Resamples a mono int16 voice recording from fromRate to toRate with a typical
+// speech chain: a high-pass filter that removes rumble below 80 Hz, peak
+// normalization to -1 dBFS and clamping. Returns nil if the rates are invalid.
+func ProcessVoice(data []int16, fromRate, toRate int) []int16 {
+	r, err := NewResampler(1, fromRate, toRate)
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+
+	hp := newHighPass(float64(fromRate), 80, 1/math.Sqrt2)
+	f64 := make([]float64, len(data))
+	for i, v := range data {
+		f64[i] = hp.process(r.fromInt16(v))
+	}
+
+	resampled := r.ResampleFloat64(f64)
+
+	peak := 0.0
+	for _, v := range resampled {
+		peak = math.Max(peak, math.Abs(v))
+	}
+	gain := 1.0
+	if peak > 0 {
+		gain = math.Pow(10, -1.0/20) / peak
+	}
+
+	out := make([]int16, len(resampled))
+	for i, v := range resampled {
+		out[i] = r.toInt16(v * gain)
+	}
+	return out
+}
@@ -0,0 +1,76 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import (
+	"math"
+	"testing"
+)
+
+// Returns the amplitude and phase of the freq component of one channel of data.
+func tone(data []float64, channels, channel int, freq, rate float64) (amplitude, phase float64) {
+	var re, im float64
+	n := len(data) / channels
+	for i := 0; i < n; i++ {
+		w := 2 * math.Pi * freq * float64(i) / rate
+		re += data[i*channels+channel] * math.Cos(w)
+		im += data[i*channels+channel] * math.Sin(w)
+	}
+	return 2 * math.Hypot(re, im) / float64(n), math.Atan2(im, re)
+}
+
+func TestProcessVoice(t *testing.T) {
+	in := make([]int16, 16000)
+	for i := range in {
+		x := float64(i) / 16000
+		in[i] = int16(8000*math.Sin(2*math.Pi*30*x) + 2000*math.Sin(2*math.Pi*1000*x))
+	}
+	out := ProcessVoice(in, 16000, 8000)
+	if len(out) != 8000 {
+		t.Fatalf("got %d samples, want 8000", len(out))
+	}
+
+	peak := 0.0
+	f64 := make([]float64, len(out))
+	for i, v := range out {
+		peak = math.Max(peak, math.Abs(float64(v)))
+		f64[i] = float64(v)
+	}
+	if want := 32767 * math.Pow(10, -1.0/20); math.Abs(peak-want) > 2 {
+		t.Errorf("peak is %v, want %v (-1 dBFS)", peak, want)
+	}
+	// The rumble starts out at 4 times the level of the voice band tone; the
+	// high-pass filter takes 17 dB off at 30 Hz.
+	rumble, _ := tone(f64, 1, 0, 30, 8000)
+	voice, _ := tone(f64, 1, 0, 1000, 8000)
+	if rumble > 0.6*voice {
+		t.Errorf("30 Hz rumble is %v against %v at 1 kHz, want it filtered", rumble, voice)
+	}
+
+	if ProcessVoice(in, 0, 8000) != nil || ProcessVoice(nil, 16000, 8000) != nil {
+		t.Error("expected nil for invalid rates or empty input")
+	}
+}
@@ -0,0 +1,315 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// The encoding of PCM samples in a byte stream. All formats are little-endian,
+// like WAV files.
+type SampleFormat int
+
+const (
+	FormatInt16   SampleFormat = iota // Signed 16-bit integers.
+	FormatFloat32                     // 32-bit IEEE floats.
+)
+
+// Returns the size of one sample in bytes.
+func (f SampleFormat) bytes() int {
+	switch f {
+	case FormatInt16:
+		return 2
+	case FormatFloat32:
+		return 4
+	}
+	return 0
+}
+
+// Returns the WAVE format tag and bits per sample of the format.
+func (f SampleFormat) wavFormat() (tag uint16, bits uint16) {
+	if f == FormatFloat32 {
+		return 3, 32
+	}
+	return 1, 16
+}
+
+// Decodes the samples in raw, which must hold a whole amount of samples.
+func (r *Resampler) decodeSamples(dst []float64, raw []byte, format SampleFormat) []float64 {
+	size := format.bytes()
+	for i := 0; i+size <= len(raw); i += size {
+		switch format {
+		case FormatInt16:
+			dst = append(dst, r.fromInt16(int16(binary.LittleEndian.Uint16(raw[i:]))))
+		case FormatFloat32:
+			dst = append(dst, float64(math.Float32frombits(binary.LittleEndian.Uint32(raw[i:]))))
+		}
+	}
+	return dst
+}
+
+// Encodes samples, appending them to dst.
+func (r *Resampler) encodeSamples(dst []byte, samples []float64, format SampleFormat) []byte {
+	for _, v := range samples {
+		switch format {
+		case FormatInt16:
+			dst = binary.LittleEndian.AppendUint16(dst, uint16(r.toInt16(v)))
+		case FormatFloat32:
+			dst = binary.LittleEndian.AppendUint32(dst, math.Float32bits(float32(v)))
+		}
+	}
+	return dst
+}
+
+// Writes a canonical 44 byte WAV header for dataSize bytes of sample data.
+func writeWAVHeader(w io.Writer, format SampleFormat, rate, channels int, dataSize uint32) error {
+	tag, bits := format.wavFormat()
+	blockAlign := uint16(channels) * bits / 8
+
+	header := make([]byte, 0, 44)
+	header = append(header, "RIFF"...)
+	header = binary.LittleEndian.AppendUint32(header, 36+dataSize)
+	header = append(header, "WAVEfmt "...)
+	header = binary.LittleEndian.AppendUint32(header, 16)
+	header = binary.LittleEndian.AppendUint16(header, tag)
+	header = binary.LittleEndian.AppendUint16(header, uint16(channels))
+	header = binary.LittleEndian.AppendUint32(header, uint32(rate))
+	header = binary.LittleEndian.AppendUint32(header, uint32(rate)*uint32(blockAlign))
+	header = binary.LittleEndian.AppendUint16(header, blockAlign)
+	header = binary.LittleEndian.AppendUint16(header, bits)
+	header = append(header, "data"...)
+	header = binary.LittleEndian.AppendUint32(header, dataSize)
+
+	_, err := w.Write(header)
+	return err
+}
+
+// Reads interleaved PCM in inFmt from src, resamples it from inRate to outRate
+// and writes it to a new WAV file at outPath in the same format. The input is
+// streamed, so the whole result is never held in memory; the sizes in the WAV
+// header are filled in once everything is written.
+func ResampleToWAVFile(src io.Reader, inFmt SampleFormat, inRate int, outPath string, outRate, channels int) (err error) {
+	if inFmt.bytes() == 0 {
+		return fmt.Errorf("unknown sample format %d", inFmt)
+	}
+	r, err := NewResampler(channels, inRate, outRate)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	if err := writeWAVHeader(file, inFmt, outRate, channels, 0); err != nil {
+		return err
+	}
+
+	stream := r.Stream()
+	frameBytes := inFmt.bytes() * channels
+	buf := make([]byte, 4096*frameBytes)
+	var samples []float64
+	var encoded []byte
+	written := 0
+	write := func(out []float64) error {
+		encoded = r.encodeSamples(encoded[:0], out, inFmt)
+		n, err := file.Write(encoded)
+		written += n
+		return err
+	}
+
+	pending := 0
+	for {
+		n, readErr := src.Read(buf[pending:])
+		pending += n
+		whole := pending - pending%frameBytes
+		if whole > 0 {
+			samples = r.decodeSamples(samples[:0], buf[:whole], inFmt)
+			out, _, _ := stream.Process(samples)
+			if err := write(out); err != nil {
+				return err
+			}
+			pending = copy(buf, buf[whole:pending])
+		}
+		if errors.Is(readErr, io.EOF) {
+			break
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+	if pending != 0 {
+		return fmt.Errorf("input ends with a partial frame of %d bytes", pending)
+	}
+	if err := write(stream.Flush()); err != nil {
+		return err
+	}
+
+	// Patch the RIFF and data chunk sizes
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return writeWAVHeader(file, inFmt, outRate, channels, uint32(written))
+}
+
+// Parses a WAV file held in memory. Returns the sample format, rate and channel
+// count from its fmt chunk and the contents of its data chunk.
+func parseWAV(wav []byte) (format SampleFormat, rate, channels int, data []byte, err error) {
+	if len(wav) < 12 || string(wav[0:4]) != "RIFF" || string(wav[8:12]) != "WAVE" {
+		return 0, 0, 0, nil, errors.New("not a RIFF WAVE file")
+	}
+
+	var fmtChunk []byte
+	foundData := false
+	for rest := wav[12:]; len(rest) >= 8; {
+		id, size := string(rest[0:4]), int(binary.LittleEndian.Uint32(rest[4:8]))
+		rest = rest[8:]
+		if size > len(rest) {
+			// Truncated, or written with a placeholder size
+			size = len(rest)
+		}
+		switch id {
+		case "fmt ":
+			fmtChunk = rest[:size]
+		case "data":
+			data, foundData = rest[:size], true
+		}
+		// Chunks are padded to an even size
+		rest = rest[size:]
+		if size%2 != 0 && len(rest) > 0 {
+			rest = rest[1:]
+		}
+	}
+	if len(fmtChunk) < 16 {
+		return 0, 0, 0, nil, errors.New("WAV file has no valid fmt chunk")
+	}
+	if !foundData {
+		return 0, 0, 0, nil, errors.New("WAV file has no data chunk")
+	}
+
+	tag := binary.LittleEndian.Uint16(fmtChunk[0:])
+	channels = int(binary.LittleEndian.Uint16(fmtChunk[2:]))
+	rate = int(binary.LittleEndian.Uint32(fmtChunk[4:]))
+	bits := binary.LittleEndian.Uint16(fmtChunk[14:])
+	if tag == 0xFFFE && len(fmtChunk) >= 26 {
+		// WAVE_FORMAT_EXTENSIBLE keeps the actual format in its sub-format GUID
+		tag = binary.LittleEndian.Uint16(fmtChunk[24:])
+	}
+	switch {
+	case tag == 1 && bits == 16:
+		format = FormatInt16
+	case tag == 3 && bits == 32:
+		format = FormatFloat32
+	default:
+		return 0, 0, 0, nil, fmt.Errorf("unsupported WAV format %d with %d bits per sample (only 16-bit PCM and 32-bit float are supported)", tag, bits)
+	}
+	return format, rate, channels, data, nil
+}
+
+// Reads the sample format, rate and channel count of a WAV file held in memory,
+// along with the amount of samples (over all channels) in its data chunk.
+// Returns an error for unsupported formats and malformed files.
+func WAVInfo(wav []byte) (format SampleFormat, rate, channels, samples int, err error) {
+	format, rate, channels, data, err := parseWAV(wav)
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+	return format, rate, channels, len(data) / format.bytes(), nil
+}
+
+// Resamples a WAV file held in memory to targetRate and returns the result as a
+// new WAV file in the same sample format. The rate, channel count and format are
+// read from the fmt chunk; 16-bit PCM and 32-bit float data are supported.
+// Returns an error for other formats and malformed files.
+func ResampleWAVBytes(wav []byte, targetRate int) ([]byte, error) {
+	format, rate, channels, data, err := parseWAV(wav)
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewResampler(channels, rate, targetRate)
+	if err != nil {
+		return nil, err
+	}
+
+	frameBytes := format.bytes() * channels
+	samples := r.decodeSamples(nil, data[:len(data)-len(data)%frameBytes], format)
+	pcm := r.encodeSamples(nil, r.ResampleFloat64(samples), format)
+
+	var out bytes.Buffer
+	if err := writeWAVHeader(&out, format, targetRate, channels, uint32(len(pcm))); err != nil {
+		return nil, err
+	}
+	out.Write(pcm)
+	return out.Bytes(), nil
+}
+
+// Resamples the 16-bit PCM WAV file at inPath to outputRate and writes the
+// result as a new 16-bit PCM WAV file at outPath. The input rate and channel
+// count are read from the fmt chunk and the data chunk goes through
+// ResampleInt16. Returns an error for other formats and malformed files.
+func ResampleWAVFile(inPath, outPath string, outputRate int) error {
+	wav, err := os.ReadFile(inPath)
+	if err != nil {
+		return err
+	}
+	format, rate, channels, data, err := parseWAV(wav)
+	if err != nil {
+		return err
+	}
+	if format != FormatInt16 {
+		return fmt.Errorf("%s is not 16-bit PCM", inPath)
+	}
+	r, err := NewResampler(channels, rate, outputRate)
+	if err != nil {
+		return err
+	}
+
+	frameBytes := format.bytes() * channels
+	samples := r.ResampleInt16(decodeInt16(data[:len(data)-len(data)%frameBytes], binary.LittleEndian))
+	pcm := make([]byte, 2*len(samples))
+	for i, v := range samples {
+		binary.LittleEndian.PutUint16(pcm[2*i:], uint16(v))
+	}
+
+	var out bytes.Buffer
+	if err := writeWAVHeader(&out, format, outputRate, channels, uint32(len(pcm))); err != nil {
+		return err
+	}
+	out.Write(pcm)
+	return os.WriteFile(outPath, out.Bytes(), 0o644)
+}
@@ -0,0 +1,155 @@
+// This is free and unencumbered software released into the public domain.
+//
+// Anyone is free to copy, modify, publish, use, compile, sell, or
+// distribute this software, either in source code form or as a compiled
+// binary, for any purpose, commercial or non-commercial, and by any
+// means.
+//
+// In jurisdictions that recognize copyright laws, the author or authors
+// of this software dedicate any and all copyright interest in the
+// software to the public domain. We make this dedication for the benefit
+// of the public at large and to the detriment of our heirs and
+// successors. We intend this dedication to be an overt act of
+// relinquishment in perpetuity of all present and future rights to this
+// software under copyright law.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+// IN NO EVENT SHALL THE AUTHORS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+//
+// For more information, please refer to <http://unlicense.org/>
+
+package gomplerate
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResampleToWAVFile(t *testing.T) {
+	dir := t.TempDir()
+	r := mustResampler(t, 2, 44100, 48000)
+	for _, format := range []SampleFormat{FormatInt16, FormatFloat32} {
+		raw := r.encodeSamples(nil, sine(1000, 2, 440, 44100), format)
+		path := filepath.Join(dir, "out.wav")
+		if err := ResampleToWAVFile(bytes.NewReader(raw), format, 44100, path, 48000, 2); err != nil {
+			t.Fatal(err)
+		}
+		wav, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		gotFormat, rate, channels, data, err := parseWAV(wav)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gotFormat != format || rate != 48000 || channels != 2 {
+			t.Fatalf("got format %d at %d Hz with %d channels", gotFormat, rate, channels)
+		}
+		want := r.encodeSamples(nil, r.ResampleFloat64(r.decodeSamples(nil, raw, format)), format)
+		if !bytes.Equal(data, want) {
+			t.Fatalf("format %d: got %d bytes of data that differ from the %d expected", format, len(data), len(want))
+		}
+		if riff := binary.LittleEndian.Uint32(wav[4:]); int(riff) != 36+len(want) {
+			t.Errorf("format %d: RIFF size is %d, want %d", format, riff, 36+len(want))
+		}
+	}
+
+	err := ResampleToWAVFile(bytes.NewReader(make([]byte, 6)), FormatInt16, 44100, filepath.Join(dir, "partial.wav"), 48000, 2)
+	if err == nil {
+		t.Error("expected an error for a partial frame")
+	}
+	if err := ResampleToWAVFile(bytes.NewReader(nil), SampleFormat(5), 44100, filepath.Join(dir, "bad.wav"), 48000, 2); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+// Returns a WAV file holding samples in the given format.
+func makeWAV(t *testing.T, samples []float64, format SampleFormat, rate, channels int) []byte {
+	t.Helper()
+	pcm := mustResampler(t, channels, rate, rate).encodeSamples(nil, samples, format)
+	var wav bytes.Buffer
+	if err := writeWAVHeader(&wav, format, rate, channels, uint32(len(pcm))); err != nil {
+		t.Fatal(err)
+	}
+	wav.Write(pcm)
+	return wav.Bytes()
+}
+
+func TestResampleWAVBytes(t *testing.T) {
+	r := mustResampler(t, 2, 44100, 48000)
+	for _, format := range []SampleFormat{FormatInt16, FormatFloat32} {
+		in := makeWAV(t, sine(1000, 2, 440, 44100), format, 44100, 2)
+		if gotFormat, rate, channels, samples, err := WAVInfo(in); err != nil || gotFormat != format || rate != 44100 || channels != 2 || samples != 2000 {
+			t.Fatalf("WAVInfo: got format %d at %d Hz with %d channels and %d samples (%v)", gotFormat, rate, channels, samples, err)
+		}
+
+		out, err := ResampleWAVBytes(in, 48000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gotFormat, rate, channels, samples, err := WAVInfo(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gotFormat != format || rate != 48000 || channels != 2 || samples != r.OutputLen(2000) {
+			t.Errorf("got format %d at %d Hz with %d channels and %d samples", gotFormat, rate, channels, samples)
+		}
+	}
+
+	adpcm := makeWAV(t, make([]float64, 4), FormatInt16, 8000, 1)
+	binary.LittleEndian.PutUint16(adpcm[20:], 2)
+	if _, err := ResampleWAVBytes(adpcm, 16000); err == nil {
+		t.Error("expected an error for an ADPCM file")
+	}
+	if _, _, _, _, err := WAVInfo([]byte("RIFF")); err == nil {
+		t.Error("expected an error for a truncated file")
+	}
+}
+
+func TestResampleWAVFile(t *testing.T) {
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, "in.wav"), filepath.Join(dir, "out.wav")
+	samples := sine(441, 2, 440, 44100)
+	if err := os.WriteFile(in, makeWAV(t, samples, FormatInt16, 44100, 2), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ResampleWAVFile(in, out, 48000); err != nil {
+		t.Fatal(err)
+	}
+	wav, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	format, rate, channels, data, err := parseWAV(wav)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != FormatInt16 || rate != 48000 || channels != 2 {
+		t.Fatalf("got format %d at %d Hz with %d channels", format, rate, channels)
+	}
+	r := mustResampler(t, 2, 44100, 48000)
+	want := encodeInt16(r.ResampleInt16(decodeInt16(r.encodeSamples(nil, samples, FormatInt16), binary.LittleEndian)), binary.LittleEndian)
+	if !bytes.Equal(data, want) {
+		t.Errorf("got %d bytes of data that differ from the %d expected", len(data), len(want))
+	}
+
+	float := filepath.Join(dir, "float.wav")
+	if err := os.WriteFile(float, makeWAV(t, samples, FormatFloat32, 44100, 2), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ResampleWAVFile(float, out, 48000); err == nil {
+		t.Error("expected an error for a 32-bit float file")
+	}
+	if err := ResampleWAVFile(filepath.Join(dir, "missing.wav"), out, 48000); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}